@@ -0,0 +1,42 @@
+package client
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var auditLogPath = "/var/log/boops/audit.log"
+
+// AuditEntry is one JSON line of the audit log, recording a change the agent
+// made (or tried to make) to the host
+type AuditEntry struct {
+	Time    string      `json:"time"`
+	Action  string      `json:"action"`
+	Result  string      `json:"result"`
+	Error   string      `json:"error,omitempty"`
+	Changes interface{} `json:"changes,omitempty"`
+}
+
+// AppendAuditLog appends entry to the audit log, stamping it with the current time
+func AppendAuditLog(entry AuditEntry) error {
+	if entry.Time == "" {
+		entry.Time = time.Now().UTC().Format(time.RFC3339)
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(auditLogPath), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(auditLogPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0640)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
@@ -3,16 +3,84 @@ package client
 import (
 	"encoding/json"
 	"os"
+	"path"
 )
 
 type Config struct {
 	ID string `json:"id"`
+	// DriftPolicy decides what sync does when the live network configuration
+	// no longer matches the server record: "report" (default) or "remediate"
+	DriftPolicy string `json:"drift_policy,omitempty"`
+	// InterfaceFilter selects the interfaces "boops regist" sends
+	InterfaceFilter *InterfaceFilter `json:"interface_filter,omitempty"`
+	// LLDPListen lets sync capture LLDP frames itself when lldpd isn't
+	// running. It needs root and adds up to 40 seconds to each run.
+	LLDPListen bool `json:"lldp_listen,omitempty"`
+}
+
+// InterfaceFilter decides which interfaces are registered. Name rules are
+// shell globs such as "docker*".
+type InterfaceFilter struct {
+	// Include limits registration to matching names; empty means all
+	Include []string `json:"include,omitempty"`
+	// Exclude drops matching names. Unset means DefaultInterfaceExclude; an
+	// empty list excludes nothing.
+	Exclude []string `json:"exclude"`
+	// Types limits registration to interface kinds such as "physical",
+	// "bond" or "vlan"; empty means all
+	Types []string `json:"types,omitempty"`
+	// UpOnly skips interfaces that are administratively or link down
+	UpOnly bool `json:"up_only,omitempty"`
+}
+
+// DefaultInterfaceExclude skips loopback and container/virtualization
+// plumbing that doesn't belong in the machine record
+var DefaultInterfaceExclude = []string{"lo", "docker*", "veth*", "cali*", "br-*", "virbr*", "flannel*", "cni*"}
+
+// Filter returns the configured interface filter or the default one
+func (c *Config) Filter() InterfaceFilter {
+	if c == nil || c.InterfaceFilter == nil {
+		return InterfaceFilter{Exclude: DefaultInterfaceExclude}
+	}
+	f := *c.InterfaceFilter
+	if f.Exclude == nil {
+		f.Exclude = DefaultInterfaceExclude
+	}
+	return f
+}
+
+// Allows reports whether an interface passes the filter
+func (f InterfaceFilter) Allows(name, kind string, up bool) bool {
+	if len(f.Include) > 0 && !matchAny(f.Include, name) {
+		return false
+	}
+	if matchAny(f.Exclude, name) {
+		return false
+	}
+	if len(f.Types) > 0 && !matchAny(f.Types, kind) {
+		return false
+	}
+	return up || !f.UpOnly
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
 }
 
 var configPath = "/etc/boops/config.json"
 
+// SaveConfig stores the machine ID, keeping any other settings already present
 func SaveConfig(id string) error {
-	cfg := Config{ID: id}
+	cfg, err := LoadConfig()
+	if err != nil {
+		cfg = &Config{}
+	}
+	cfg.ID = id
 	data, _ := json.Marshal(cfg)
 	os.MkdirAll("/etc/boops", 0755)
 	return os.WriteFile(configPath, data, 0644)
@@ -50,59 +118,3 @@ func LoadMachineState() (*MachineState, error) {
 	err = json.Unmarshal(data, &state)
 	return &state, err
 }
-
-// InterfacesEqual compares two interface maps for equality
-func InterfacesEqual(a, b []InterfaceInfo) bool {
-	if len(a) != len(b) {
-		return false
-	}
-
-	// Create maps to preserve the name->info relationship for comparison
-	aMap := make(map[string]InterfaceInfo)
-	bMap := make(map[string]InterfaceInfo)
-
-	for _, info := range a {
-		if info.IPs != nil && len(info.IPs) > 0 {
-			aMap[info.IPs[0].IP] = info // Using first IP as key for simplicity
-		}
-	}
-
-	for _, info := range b {
-		if info.IPs != nil && len(info.IPs) > 0 {
-			bMap[info.IPs[0].IP] = info // Using first IP as key for simplicity
-		}
-	}
-
-	for name, infoA := range aMap {
-		infoB, exists := bMap[name]
-		if !exists || infoA.Gateway != infoB.Gateway || len(infoA.IPs) != len(infoB.IPs) {
-			return false
-		}
-
-		// Check if IPs match (ignoring order)
-		aIpMap := make(map[string]string)
-		for _, ipInfo := range infoA.IPs {
-			aIpMap[ipInfo.IP] = ipInfo.Subnet
-		}
-		bIpMap := make(map[string]string)
-		for _, ipInfo := range infoB.IPs {
-			bIpMap[ipInfo.IP] = ipInfo.Subnet
-		}
-
-		if len(aIpMap) != len(bIpMap) {
-			return false
-		}
-
-		for ip, subnet := range aIpMap {
-			if bSubnet, exists := bIpMap[ip]; !exists || subnet != bSubnet {
-				return false
-			}
-		}
-
-		// Compare DNS servers as well
-		if infoA.DnsServers != infoB.DnsServers {
-			return false
-		}
-	}
-	return true
-}
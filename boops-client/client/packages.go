@@ -0,0 +1,40 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrPackagesOutOfSync means the server's package list is not the one the
+// delta was computed against, so the full list has to be sent again
+var ErrPackagesOutOfSync = errors.New("server package list is out of sync")
+
+// SendPackages posts a package update (full list or delta) for machineID
+func SendPackages(apiBase, machineID string, update interface{}) error {
+	body, err := json.Marshal(update)
+	if err != nil {
+		return fmt.Errorf("failed to encode package update: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/%s/packages", apiBase, machineID), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create package update request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send package update: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusConflict {
+		return ErrPackagesOutOfSync
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("package update rejected with status code: %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Report is a typed document the agent uploads next to the machine record.
+// The server keeps the latest report of each kind per machine.
+type Report struct {
+	Kind    string      `json:"kind"`
+	Payload interface{} `json:"payload"`
+}
+
+// SendReport posts payload as a report of the given kind for machineID
+func SendReport(apiBase, machineID, kind string, payload interface{}) error {
+	body, err := json.Marshal(Report{Kind: kind, Payload: payload})
+	if err != nil {
+		return fmt.Errorf("failed to encode %s report: %v", kind, err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/%s/reports", apiBase, machineID), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create %s report request: %v", kind, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send %s report: %v", kind, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s report rejected with status code: %d", kind, resp.StatusCode)
+	}
+	return nil
+}
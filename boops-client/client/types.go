@@ -11,13 +11,14 @@ type Machine struct {
 	CpuInfo       string          `json:"cpu_info"`
 	CpuArch       string          `json:"cpu_arch"`
 	MemorySize    string          `json:"memory_size"`
-	DiskInfo      string          `json:"disk_info"`
+	DiskInfo      interface{}     `json:"disk_info"`
 	OsName        string          `json:"os_name"`
 	IsVirtual     int             `json:"is_virtual"`
 	ParentMachine *string         `json:"parent_machine_id"`
 	CreatedAt     string          `json:"created_at"`
 	UpdatedAt     string          `json:"updated_at"`
 	Interfaces    []InterfaceInfo `json:"interfaces"`
+	SSHHostKeys   interface{}     `json:"ssh_host_keys,omitempty"`
 }
 
 type InterfaceInfo struct {
@@ -26,6 +27,11 @@ type InterfaceInfo struct {
 	Gateway    string   `json:"gateway"`
 	DnsServers string   `json:"dns_servers,omitempty"` // Receive as comma-separated string from API
 	MacAddress string   `json:"mac_address,omitempty"`
+	Mtu        int      `json:"mtu,omitempty"`
+	// LLDPNeighbors are the switch ports seen on the interface. It is only
+	// sent by the agent when LLDP could be read; the server returns them with
+	// every interface.
+	LLDPNeighbors interface{} `json:"lldp_neighbors,omitempty"`
 }
 
 type IPInfo struct {
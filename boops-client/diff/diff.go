@@ -0,0 +1,221 @@
+// Package diff computes structured, name-keyed differences between two sets
+// of network interfaces.
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"boops/client"
+)
+
+// Op is the kind of a change
+type Op string
+
+const (
+	Added    Op = "added"
+	Removed  Op = "removed"
+	Modified Op = "modified"
+)
+
+// Change is a single difference for one interface. Field is empty when the
+// whole interface was added or removed.
+type Change struct {
+	Op        Op     `json:"op"`
+	Interface string `json:"interface"`
+	Field     string `json:"field,omitempty"`
+	Old       string `json:"old,omitempty"`
+	New       string `json:"new,omitempty"`
+}
+
+func (c Change) String() string {
+	target := c.Interface
+	if c.Field != "" {
+		target += " " + c.Field
+	}
+	switch c.Op {
+	case Added:
+		return fmt.Sprintf("+ %s: %s", target, c.New)
+	case Removed:
+		return fmt.Sprintf("- %s: %s", target, c.Old)
+	default:
+		return fmt.Sprintf("~ %s: %s -> %s", target, c.Old, c.New)
+	}
+}
+
+// Diff is an ordered list of changes, sorted by interface and field
+type Diff []Change
+
+// Empty reports whether the two sides were identical
+func (d Diff) Empty() bool {
+	return len(d) == 0
+}
+
+// String renders one change per line using +, - and ~ prefixes
+func (d Diff) String() string {
+	lines := make([]string, 0, len(d))
+	for _, c := range d {
+		lines = append(lines, c.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Interfaces compares two interface lists by name and returns every added,
+// removed and modified interface and field needed to get from "from" to "to".
+// Interfaces without IPs are compared like any other.
+func Interfaces(from, to []client.InterfaceInfo) Diff {
+	oldMap, newMap := byName(from), byName(to)
+
+	names := make([]string, 0, len(oldMap)+len(newMap))
+	for name := range oldMap {
+		names = append(names, name)
+	}
+	for name := range newMap {
+		if _, ok := oldMap[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var d Diff
+	for _, name := range names {
+		o, inOld := oldMap[name]
+		n, inNew := newMap[name]
+		switch {
+		case !inOld:
+			d = append(d, Change{Op: Added, Interface: name, New: summary(n)})
+		case !inNew:
+			d = append(d, Change{Op: Removed, Interface: name, Old: summary(o)})
+		default:
+			d = append(d, Interface(o, n)...)
+		}
+	}
+	return d
+}
+
+// Applied compares only what applying the network configuration writes: the
+// IP addresses, gateway, DNS servers and MTU of interfaces with IPs. MAC
+// addresses and dns_register are only kept on the server, so changes to
+// them never call for a re-apply.
+func Applied(from, to []client.InterfaceInfo) Diff {
+	return Interfaces(appliedFields(from), appliedFields(to))
+}
+
+func appliedFields(ifaces []client.InterfaceInfo) []client.InterfaceInfo {
+	var result []client.InterfaceInfo
+	for _, info := range ifaces {
+		if len(info.IPs) == 0 {
+			continue
+		}
+		applied := client.InterfaceInfo{
+			Name:       info.Name,
+			Gateway:    info.Gateway,
+			DnsServers: info.DnsServers,
+			Mtu:        info.Mtu,
+		}
+		for _, ip := range info.IPs {
+			applied.IPs = append(applied.IPs, client.IPInfo{IP: ip.IP, Subnet: ip.Subnet})
+		}
+		result = append(result, applied)
+	}
+	return result
+}
+
+// Interface compares the fields of two versions of the same interface
+func Interface(o, n client.InterfaceInfo) Diff {
+	var d Diff
+	field := func(name, oldValue, newValue string) {
+		switch {
+		case oldValue == newValue:
+		case oldValue == "":
+			d = append(d, Change{Op: Added, Interface: n.Name, Field: name, New: newValue})
+		case newValue == "":
+			d = append(d, Change{Op: Removed, Interface: n.Name, Field: name, Old: oldValue})
+		default:
+			d = append(d, Change{Op: Modified, Interface: n.Name, Field: name, Old: oldValue, New: newValue})
+		}
+	}
+
+	field("mac_address", strings.ToLower(o.MacAddress), strings.ToLower(n.MacAddress))
+	field("gateway", o.Gateway, n.Gateway)
+	field("dns_servers", strings.Join(SplitList(o.DnsServers), ","), strings.Join(SplitList(n.DnsServers), ","))
+	field("mtu", mtu(o.Mtu), mtu(n.Mtu))
+
+	oldIPs, newIPs := ipsByAddress(o.IPs), ipsByAddress(n.IPs)
+	addrs := make([]string, 0, len(oldIPs)+len(newIPs))
+	for addr := range oldIPs {
+		addrs = append(addrs, addr)
+	}
+	for addr := range newIPs {
+		if _, ok := oldIPs[addr]; !ok {
+			addrs = append(addrs, addr)
+		}
+	}
+	sort.Strings(addrs)
+
+	for _, addr := range addrs {
+		oi, inOld := oldIPs[addr]
+		ni, inNew := newIPs[addr]
+		switch {
+		case !inOld:
+			d = append(d, Change{Op: Added, Interface: n.Name, Field: "ips", New: addr + "/" + ni.Subnet})
+		case !inNew:
+			d = append(d, Change{Op: Removed, Interface: n.Name, Field: "ips", Old: addr + "/" + oi.Subnet})
+		default:
+			field(fmt.Sprintf("ips[%s].subnet_mask", addr), oi.Subnet, ni.Subnet)
+			if oi.DNSRegister != ni.DNSRegister {
+				d = append(d, Change{Op: Modified, Interface: n.Name, Field: fmt.Sprintf("ips[%s].dns_register", addr),
+					Old: fmt.Sprint(oi.DNSRegister), New: fmt.Sprint(ni.DNSRegister)})
+			}
+		}
+	}
+	return d
+}
+
+// SplitList turns a comma-separated value such as dns_servers into a list
+func SplitList(s string) []string {
+	var result []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func byName(ifaces []client.InterfaceInfo) map[string]client.InterfaceInfo {
+	result := make(map[string]client.InterfaceInfo, len(ifaces))
+	for _, info := range ifaces {
+		result[info.Name] = info
+	}
+	return result
+}
+
+func ipsByAddress(ips []client.IPInfo) map[string]client.IPInfo {
+	result := make(map[string]client.IPInfo, len(ips))
+	for _, ip := range ips {
+		result[ip.IP] = ip
+	}
+	return result
+}
+
+func mtu(v int) string {
+	if v == 0 {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// summary describes a whole interface for added/removed changes
+func summary(info client.InterfaceInfo) string {
+	addrs := make([]string, 0, len(info.IPs))
+	for _, ip := range info.IPs {
+		addrs = append(addrs, ip.IP+"/"+ip.Subnet)
+	}
+	sort.Strings(addrs)
+	if len(addrs) == 0 {
+		return "no addresses"
+	}
+	return strings.Join(addrs, ", ")
+}
@@ -0,0 +1,184 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+
+	"boops/client"
+)
+
+var eth0 = client.InterfaceInfo{
+	Name: "eth0",
+	IPs: []client.IPInfo{
+		{IP: "10.0.0.5", Subnet: "255.255.255.0", DNSRegister: 1},
+		{IP: "10.0.0.6", Subnet: "255.255.255.0"},
+	},
+	Gateway:    "10.0.0.1",
+	DnsServers: "10.0.0.53,10.0.0.54",
+	MacAddress: "52:54:00:12:34:56",
+}
+
+// edited returns a copy of eth0 changed by edit
+func edited(edit func(*client.InterfaceInfo)) client.InterfaceInfo {
+	info := eth0
+	info.IPs = append([]client.IPInfo(nil), eth0.IPs...)
+	edit(&info)
+	return info
+}
+
+func TestInterfaces(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to []client.InterfaceInfo
+		want     Diff
+	}{
+		{
+			name: "unchanged",
+			from: []client.InterfaceInfo{eth0},
+			to:   []client.InterfaceInfo{eth0},
+		},
+		{
+			name: "added and removed interfaces",
+			from: []client.InterfaceInfo{eth0, {Name: "eth1"}},
+			to:   []client.InterfaceInfo{eth0, {Name: "eth2", IPs: []client.IPInfo{{IP: "192.168.0.5", Subnet: "255.255.255.0"}}}},
+			want: Diff{
+				{Op: Removed, Interface: "eth1", Old: "no addresses"},
+				{Op: Added, Interface: "eth2", New: "192.168.0.5/255.255.255.0"},
+			},
+		},
+		{
+			name: "renamed interface",
+			from: []client.InterfaceInfo{eth0},
+			to:   []client.InterfaceInfo{edited(func(i *client.InterfaceInfo) { i.Name = "ens3" })},
+			want: Diff{
+				{Op: Added, Interface: "ens3", New: "10.0.0.5/255.255.255.0, 10.0.0.6/255.255.255.0"},
+				{Op: Removed, Interface: "eth0", Old: "10.0.0.5/255.255.255.0, 10.0.0.6/255.255.255.0"},
+			},
+		},
+		{
+			name: "IP order does not matter",
+			from: []client.InterfaceInfo{eth0},
+			to: []client.InterfaceInfo{edited(func(i *client.InterfaceInfo) {
+				i.IPs[0], i.IPs[1] = i.IPs[1], i.IPs[0]
+			})},
+		},
+		{
+			name: "DNS whitespace and MAC case do not matter",
+			from: []client.InterfaceInfo{eth0},
+			to: []client.InterfaceInfo{edited(func(i *client.InterfaceInfo) {
+				i.DnsServers = " 10.0.0.53 , 10.0.0.54,"
+				i.MacAddress = "52:54:00:12:34:56"
+			})},
+		},
+		{
+			name: "DNS order matters",
+			from: []client.InterfaceInfo{eth0},
+			to:   []client.InterfaceInfo{edited(func(i *client.InterfaceInfo) { i.DnsServers = "10.0.0.54,10.0.0.53" })},
+			want: Diff{{Op: Modified, Interface: "eth0", Field: "dns_servers", Old: "10.0.0.53,10.0.0.54", New: "10.0.0.54,10.0.0.53"}},
+		},
+		{
+			name: "modified fields",
+			from: []client.InterfaceInfo{eth0},
+			to: []client.InterfaceInfo{edited(func(i *client.InterfaceInfo) {
+				i.MacAddress = "52:54:00:AA:BB:CC"
+				i.Gateway = ""
+				i.Mtu = 9000
+				i.IPs[0].Subnet = "255.255.0.0"
+				i.IPs[0].DNSRegister = 0
+				i.IPs[1].IP = "10.0.0.7"
+			})},
+			want: Diff{
+				{Op: Modified, Interface: "eth0", Field: "mac_address", Old: "52:54:00:12:34:56", New: "52:54:00:aa:bb:cc"},
+				{Op: Removed, Interface: "eth0", Field: "gateway", Old: "10.0.0.1"},
+				{Op: Added, Interface: "eth0", Field: "mtu", New: "9000"},
+				{Op: Modified, Interface: "eth0", Field: "ips[10.0.0.5].subnet_mask", Old: "255.255.255.0", New: "255.255.0.0"},
+				{Op: Modified, Interface: "eth0", Field: "ips[10.0.0.5].dns_register", Old: "1", New: "0"},
+				{Op: Removed, Interface: "eth0", Field: "ips", Old: "10.0.0.6/255.255.255.0"},
+				{Op: Added, Interface: "eth0", Field: "ips", New: "10.0.0.7/255.255.255.0"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Interfaces(tt.from, tt.to); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Interfaces() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplied(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to []client.InterfaceInfo
+		want     Diff
+	}{
+		{
+			name: "MAC address and dns_register are not applied",
+			from: []client.InterfaceInfo{eth0},
+			to: []client.InterfaceInfo{edited(func(i *client.InterfaceInfo) {
+				i.MacAddress = "52:54:00:aa:bb:cc"
+				i.IPs[0].DNSRegister = 0
+			})},
+		},
+		{
+			name: "interfaces without IPs are not applied",
+			from: []client.InterfaceInfo{eth0},
+			to:   []client.InterfaceInfo{eth0, {Name: "docker0", MacAddress: "02:42:ac:11:00:02"}},
+		},
+		{
+			name: "IP order and DNS whitespace",
+			from: []client.InterfaceInfo{eth0},
+			to: []client.InterfaceInfo{edited(func(i *client.InterfaceInfo) {
+				i.IPs[0], i.IPs[1] = i.IPs[1], i.IPs[0]
+				i.DnsServers = "10.0.0.53, 10.0.0.54"
+			})},
+		},
+		{
+			name: "applied settings",
+			from: []client.InterfaceInfo{eth0},
+			to: []client.InterfaceInfo{edited(func(i *client.InterfaceInfo) {
+				i.Gateway = "10.0.0.254"
+				i.DnsServers = "10.0.0.53"
+				i.Mtu = 1400
+				i.IPs = i.IPs[:1]
+			})},
+			want: Diff{
+				{Op: Modified, Interface: "eth0", Field: "gateway", Old: "10.0.0.1", New: "10.0.0.254"},
+				{Op: Modified, Interface: "eth0", Field: "dns_servers", Old: "10.0.0.53,10.0.0.54", New: "10.0.0.53"},
+				{Op: Added, Interface: "eth0", Field: "mtu", New: "1400"},
+				{Op: Removed, Interface: "eth0", Field: "ips", Old: "10.0.0.6/255.255.255.0"},
+			},
+		},
+		{
+			name: "interface losing its IPs",
+			from: []client.InterfaceInfo{eth0},
+			to:   []client.InterfaceInfo{edited(func(i *client.InterfaceInfo) { i.IPs = nil })},
+			want: Diff{{Op: Removed, Interface: "eth0", Old: "10.0.0.5/255.255.255.0, 10.0.0.6/255.255.255.0"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Applied(tt.from, tt.to); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Applied() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{" , ,", nil},
+		{"10.0.0.53", []string{"10.0.0.53"}},
+		{" 10.0.0.53 ,10.0.0.54,, ", []string{"10.0.0.53", "10.0.0.54"}},
+	}
+	for _, tt := range tests {
+		if got := SplitList(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitList(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Package drift compares the live network configuration of the host with
+// the desired state stored in BoopsDB.
+package drift
+
+import (
+	"sort"
+	"strings"
+
+	"boops/client"
+	"boops/diff"
+)
+
+// Policies understood by the drift_policy config setting
+const (
+	PolicyReport    = "report"
+	PolicyRemediate = "remediate"
+)
+
+// Policy normalizes a configured policy, falling back to PolicyReport
+func Policy(configured string) string {
+	if strings.ToLower(strings.TrimSpace(configured)) == PolicyRemediate {
+		return PolicyRemediate
+	}
+	return PolicyReport
+}
+
+// Report is the drift result uploaded to the server after each sync. In
+// Items, Old is the desired value and New the live one.
+type Report struct {
+	Policy     string    `json:"policy"`
+	Drifted    bool      `json:"drifted"`
+	Remediated bool      `json:"remediated"`
+	Items      diff.Diff `json:"items,omitempty"`
+	// Absent lists desired interfaces that don't exist on the host (or are
+	// excluded by the interface filter). They are not drift: applying the
+	// configuration again could not bring them back.
+	Absent []string `json:"absent,omitempty"`
+}
+
+// Detect compares every interface the agent manages (those with IPs in the
+// desired state) against the live configuration and returns the drift along
+// with the managed interfaces missing from the host. Only the settings
+// BoopsDB actually stores are compared: desired IPv4 addresses that are
+// missing or carry another mask, and the gateway, DNS servers and MTU when
+// the desired state sets them. Extra live addresses such as a keepalived VIP
+// or a DHCP lease are not drift.
+func Detect(desired client.Machine, live map[string]client.InterfaceInfo) (diff.Diff, []string) {
+	// resolv.conf is global, so DNS is compared against every live resolver
+	liveDNS := make(map[string]bool)
+	for _, info := range live {
+		for _, dns := range diff.SplitList(info.DnsServers) {
+			liveDNS[dns] = true
+		}
+	}
+	resolvers := make([]string, 0, len(liveDNS))
+	for dns := range liveDNS {
+		resolvers = append(resolvers, dns)
+	}
+	sort.Strings(resolvers)
+
+	var want, have []client.InterfaceInfo
+	var absent []string
+	for _, info := range desired.Interfaces {
+		if len(info.IPs) == 0 {
+			continue
+		}
+		current, ok := live[info.Name]
+		if !ok {
+			absent = append(absent, info.Name)
+			continue
+		}
+		info = managed(info)
+		want = append(want, info)
+		have = append(have, project(info, current, liveDNS, resolvers))
+	}
+	sort.Strings(absent)
+	return diff.Interfaces(want, have), absent
+}
+
+// managed strips a desired interface down to the settings the agent applies
+func managed(info client.InterfaceInfo) client.InterfaceInfo {
+	result := client.InterfaceInfo{
+		Name:       info.Name,
+		DnsServers: strings.Join(diff.SplitList(info.DnsServers), ","),
+		MacAddress: info.MacAddress,
+		Mtu:        info.Mtu,
+	}
+	if info.Gateway != "0.0.0.0" {
+		result.Gateway = info.Gateway
+	}
+	for _, ip := range info.IPs {
+		if !strings.Contains(ip.IP, ":") {
+			result.IPs = append(result.IPs, ip)
+		}
+	}
+	return result
+}
+
+// project maps the live interface onto the fields present in want, so unset
+// desired fields never show up as drift
+func project(want, current client.InterfaceInfo, liveDNS map[string]bool, resolvers []string) client.InterfaceInfo {
+	result := client.InterfaceInfo{
+		Name:       current.Name,
+		MacAddress: want.MacAddress, // Hardware identity is synced separately, not drift
+	}
+
+	// Only the desired addresses are looked up; dns_register only exists on
+	// the server side, so it is carried over
+	liveIPs := make(map[string]string)
+	for _, ip := range current.IPs {
+		liveIPs[ip.IP] = ip.Subnet
+	}
+	for _, ip := range want.IPs {
+		if subnet, ok := liveIPs[ip.IP]; ok {
+			result.IPs = append(result.IPs, client.IPInfo{IP: ip.IP, Subnet: subnet, DNSRegister: ip.DNSRegister})
+		}
+	}
+
+	if want.Gateway != "" {
+		result.Gateway = current.Gateway
+	}
+	if wanted := diff.SplitList(want.DnsServers); len(wanted) > 0 {
+		result.DnsServers = want.DnsServers
+		for _, dns := range wanted {
+			if !liveDNS[dns] {
+				result.DnsServers = strings.Join(resolvers, ",")
+				break
+			}
+		}
+	}
+	if want.Mtu > 0 {
+		result.Mtu = current.Mtu
+	}
+	return result
+}
@@ -0,0 +1,139 @@
+package drift
+
+import (
+	"reflect"
+	"testing"
+
+	"boops/client"
+	"boops/diff"
+)
+
+func TestDetect(t *testing.T) {
+	eth0 := client.InterfaceInfo{
+		Name:       "eth0",
+		IPs:        []client.IPInfo{{IP: "10.0.0.5", Subnet: "255.255.255.0", DNSRegister: 1}},
+		Gateway:    "10.0.0.1",
+		DnsServers: "10.0.0.53, 10.0.0.54",
+		MacAddress: "52:54:00:12:34:56",
+	}
+	liveEth0 := client.InterfaceInfo{
+		Name:       "eth0",
+		IPs:        []client.IPInfo{{IP: "10.0.0.5", Subnet: "255.255.255.0"}},
+		Gateway:    "10.0.0.1",
+		DnsServers: "10.0.0.53,10.0.0.54",
+		MacAddress: "52:54:00:aa:bb:cc",
+		Mtu:        1500,
+	}
+	with := func(info client.InterfaceInfo, edit func(*client.InterfaceInfo)) client.InterfaceInfo {
+		info.IPs = append([]client.IPInfo(nil), info.IPs...)
+		edit(&info)
+		return info
+	}
+
+	tests := []struct {
+		name       string
+		desired    []client.InterfaceInfo
+		live       []client.InterfaceInfo
+		want       diff.Diff
+		wantAbsent []string
+	}{
+		{
+			name:    "in sync",
+			desired: []client.InterfaceInfo{eth0},
+			live:    []client.InterfaceInfo{liveEth0},
+		},
+		{
+			name:    "extra live addresses are not drift",
+			desired: []client.InterfaceInfo{eth0},
+			live: []client.InterfaceInfo{with(liveEth0, func(i *client.InterfaceInfo) {
+				i.IPs = append(i.IPs, client.IPInfo{IP: "10.0.0.100", Subnet: "255.255.255.255"}, client.IPInfo{IP: "fe80::1", Subnet: "64"})
+			})},
+		},
+		{
+			name:    "missing desired address",
+			desired: []client.InterfaceInfo{eth0},
+			live: []client.InterfaceInfo{with(liveEth0, func(i *client.InterfaceInfo) {
+				i.IPs = []client.IPInfo{{IP: "10.0.0.6", Subnet: "255.255.255.0"}}
+			})},
+			want: diff.Diff{{Op: diff.Removed, Interface: "eth0", Field: "ips", Old: "10.0.0.5/255.255.255.0"}},
+		},
+		{
+			name:    "changed mask",
+			desired: []client.InterfaceInfo{eth0},
+			live: []client.InterfaceInfo{with(liveEth0, func(i *client.InterfaceInfo) {
+				i.IPs[0].Subnet = "255.255.0.0"
+			})},
+			want: diff.Diff{{Op: diff.Modified, Interface: "eth0", Field: "ips[10.0.0.5].subnet_mask", Old: "255.255.255.0", New: "255.255.0.0"}},
+		},
+		{
+			name:       "absent and filtered interfaces",
+			desired:    []client.InterfaceInfo{eth0, with(eth0, func(i *client.InterfaceInfo) { i.Name = "eth1" }), {Name: "docker0"}},
+			live:       []client.InterfaceInfo{liveEth0},
+			wantAbsent: []string{"eth1"},
+		},
+		{
+			name: "unset gateway, DNS and MTU are not compared",
+			desired: []client.InterfaceInfo{with(eth0, func(i *client.InterfaceInfo) {
+				i.Gateway, i.DnsServers = "0.0.0.0", ""
+			})},
+			live: []client.InterfaceInfo{with(liveEth0, func(i *client.InterfaceInfo) {
+				i.Gateway, i.DnsServers, i.Mtu = "", "1.1.1.1", 9000
+			})},
+		},
+		{
+			name:    "gateway and MTU drift",
+			desired: []client.InterfaceInfo{with(eth0, func(i *client.InterfaceInfo) { i.Mtu = 9000 })},
+			live: []client.InterfaceInfo{with(liveEth0, func(i *client.InterfaceInfo) {
+				i.Gateway = "10.0.0.254"
+			})},
+			want: diff.Diff{
+				{Op: diff.Modified, Interface: "eth0", Field: "gateway", Old: "10.0.0.1", New: "10.0.0.254"},
+				{Op: diff.Modified, Interface: "eth0", Field: "mtu", Old: "9000", New: "1500"},
+			},
+		},
+		{
+			name:    "DNS served through another interface",
+			desired: []client.InterfaceInfo{eth0},
+			live: []client.InterfaceInfo{
+				with(liveEth0, func(i *client.InterfaceInfo) { i.DnsServers = "10.0.0.54" }),
+				{Name: "eth1", DnsServers: "10.0.0.53"},
+			},
+		},
+		{
+			name:    "missing DNS server",
+			desired: []client.InterfaceInfo{eth0},
+			live: []client.InterfaceInfo{with(liveEth0, func(i *client.InterfaceInfo) {
+				i.DnsServers = "10.0.0.53"
+			})},
+			want: diff.Diff{{Op: diff.Modified, Interface: "eth0", Field: "dns_servers", Old: "10.0.0.53,10.0.0.54", New: "10.0.0.53"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			live := make(map[string]client.InterfaceInfo)
+			for _, info := range tt.live {
+				live[info.Name] = info
+			}
+			got, absent := Detect(client.Machine{Interfaces: tt.desired}, live)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Detect() drift = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(absent, tt.wantAbsent) {
+				t.Errorf("Detect() absent = %v, want %v", absent, tt.wantAbsent)
+			}
+		})
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	for configured, want := range map[string]string{
+		"":            PolicyReport,
+		"report":      PolicyReport,
+		" Remediate ": PolicyRemediate,
+		"fix":         PolicyReport,
+	} {
+		if got := Policy(configured); got != want {
+			t.Errorf("Policy(%q) = %q, want %q", configured, got, want)
+		}
+	}
+}
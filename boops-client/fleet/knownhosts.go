@@ -0,0 +1,101 @@
+// Package fleet builds files that cover every machine registered in BoopsDB,
+// such as a known_hosts file with their SSH host keys.
+package fleet
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// hostNamePattern matches the names and addresses that may go in a
+// known_hosts host list; it leaves out wildcards, negation, separators and
+// anything that would start a new line
+var hostNamePattern = regexp.MustCompile(`^[a-z0-9._:-]+$`)
+
+// HostKey is an SSH host key as stored by the server
+type HostKey struct {
+	Type        string `json:"type"`
+	PublicKey   string `json:"public_key"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// MachineHostKeys are the host keys of one machine and the names it is
+// reached by
+type MachineHostKeys struct {
+	ID       string    `json:"id"`
+	Hostname string    `json:"hostname"`
+	IPs      []string  `json:"ips"`
+	HostKeys []HostKey `json:"host_keys"`
+}
+
+// FetchHostKeys retrieves the host keys of every machine from the API at
+// url
+func FetchHostKeys(url string) ([]MachineHostKeys, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("request failed with status code: %d", resp.StatusCode)
+	}
+
+	var machines []MachineHostKeys
+	if err := json.NewDecoder(resp.Body).Decode(&machines); err != nil {
+		return nil, fmt.Errorf("invalid JSON from API: %v", err)
+	}
+	return machines, nil
+}
+
+// KnownHosts renders a known_hosts file with one line per host key, listing
+// the machine's hostname and every registered IP as host names
+func KnownHosts(machines []MachineHostKeys) string {
+	sorted := append([]MachineHostKeys(nil), machines...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Hostname != sorted[j].Hostname {
+			return sorted[i].Hostname < sorted[j].Hostname
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	var b strings.Builder
+	b.WriteString("# Generated by boops fleet known-hosts\n")
+	for _, m := range sorted {
+		names := hostNames(m)
+		if len(names) == 0 || len(m.HostKeys) == 0 {
+			continue
+		}
+		comment := names[0]
+		if hostNamePattern.MatchString(strings.ToLower(m.ID)) {
+			comment += " (" + m.ID + ")"
+		}
+		fmt.Fprintf(&b, "# %s\n", comment)
+		for _, key := range m.HostKeys {
+			fields := strings.Fields(key.PublicKey)
+			if len(fields) < 2 {
+				continue
+			}
+			fmt.Fprintf(&b, "%s %s %s\n", strings.Join(names, ","), fields[0], fields[1])
+		}
+	}
+	return b.String()
+}
+
+// hostNames lists the hostname and IPs without duplicates
+func hostNames(m MachineHostKeys) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, name := range append([]string{m.Hostname}, m.IPs...) {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !hostNamePattern.MatchString(name) || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,74 @@
+package fleet
+
+import "testing"
+
+const (
+	ed25519Key = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAICq/MiR3erX27fAg2ZQfOJJFpXcS+dsXD2irlmPQ3tJ0"
+	ecdsaKey   = "ecdsa-sha2-nistp256 AAAAE2VjZHNhLXNoYTItbmlzdHAyNTYAAAAIbmlzdHAyNTYAAABBBJx3VJmH25IhdAOMwgBPGMSXnpwJGb5QsrUIlwp1mUwyfpRpCjbnIWTYs1plYLSd7LO4yV3NRecfejCDHEtKTRU="
+)
+
+func TestKnownHosts(t *testing.T) {
+	tests := []struct {
+		name     string
+		machines []MachineHostKeys
+		want     string
+	}{
+		{
+			name: "sorted by hostname then ID",
+			machines: []MachineHostKeys{
+				{ID: "b", Hostname: "web", IPs: []string{"10.0.0.2"}, HostKeys: []HostKey{{Type: "ssh-ed25519", PublicKey: ed25519Key + " root@web"}}},
+				{ID: "c", Hostname: "db", IPs: []string{"10.0.0.3"}, HostKeys: []HostKey{{Type: "ssh-ed25519", PublicKey: ed25519Key}}},
+				{ID: "a", Hostname: "web", IPs: []string{"10.0.0.1"}, HostKeys: []HostKey{{Type: "ssh-ed25519", PublicKey: ed25519Key}}},
+			},
+			want: "# Generated by boops fleet known-hosts\n" +
+				"# db (c)\n" +
+				"db,10.0.0.3 " + ed25519Key + "\n" +
+				"# web (a)\n" +
+				"web,10.0.0.1 " + ed25519Key + "\n" +
+				"# web (b)\n" +
+				"web,10.0.0.2 " + ed25519Key + "\n",
+		},
+		{
+			name: "one line per key, names deduplicated",
+			machines: []MachineHostKeys{
+				{ID: "a", Hostname: " DB01 ", IPs: []string{"10.0.0.3", "db01", "fe80::1", "10.0.0.3"}, HostKeys: []HostKey{
+					{Type: "ssh-ed25519", PublicKey: ed25519Key},
+					{Type: "ecdsa-sha2-nistp256", PublicKey: ecdsaKey + " root@db01"},
+					{Type: "ssh-rsa", PublicKey: "ssh-rsa"},
+				}},
+			},
+			want: "# Generated by boops fleet known-hosts\n" +
+				"# db01 (a)\n" +
+				"db01,10.0.0.3,fe80::1 " + ed25519Key + "\n" +
+				"db01,10.0.0.3,fe80::1 " + ecdsaKey + "\n",
+		},
+		{
+			name: "machines without names or keys are skipped",
+			machines: []MachineHostKeys{
+				{ID: "a", Hostname: "web", IPs: []string{"10.0.0.1"}},
+				{ID: "b", HostKeys: []HostKey{{Type: "ssh-ed25519", PublicKey: ed25519Key}}},
+			},
+			want: "# Generated by boops fleet known-hosts\n",
+		},
+		{
+			// A host pattern could make the key trusted for other hosts, and a
+			// line break would add a line of its own
+			name: "patterns and line breaks are dropped",
+			machines: []MachineHostKeys{
+				{ID: "x\n* " + ed25519Key, Hostname: "*", IPs: []string{"10.0.0.*", "!10.0.0.9", "a,b", "c d", "evil\n*", "10.0.0.4"},
+					HostKeys: []HostKey{{Type: "ssh-ed25519", PublicKey: ed25519Key}}},
+			},
+			want: "# Generated by boops fleet known-hosts\n" +
+				"# 10.0.0.4\n" +
+				"10.0.0.4 " + ed25519Key + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := KnownHosts(tt.machines); got != tt.want {
+				t.Errorf("KnownHosts() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package inventory
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Account is a local user who may be able to log in
+type Account struct {
+	Name  string `json:"name"`
+	UID   int    `json:"uid"`
+	GID   int    `json:"gid"`
+	Home  string `json:"home"`
+	Shell string `json:"shell"`
+	// AdminGroups lists the sudo, wheel and admin groups the user is in,
+	// as a primary or supplementary group
+	AdminGroups []string `json:"admin_groups,omitempty"`
+	// AuthorizedKeys are the keys allowed to log in as the user; only
+	// fingerprints are reported, never the keys themselves
+	AuthorizedKeys []AuthorizedKey `json:"authorized_keys,omitempty"`
+}
+
+// AuthorizedKey is one authorized_keys entry
+type AuthorizedKey struct {
+	SSHKey
+	File string `json:"file"`
+}
+
+// Paths relative to the root
+var (
+	passwdPath = "etc/passwd"
+	groupPath  = "etc/group"
+	shellsPath = "etc/shells"
+)
+
+// adminGroups grant root through the default sudoers or polkit rules
+var adminGroups = []string{"sudo", "wheel", "admin"}
+
+// firstUserUID is where useradd starts allocating regular accounts.
+// nobody (65534) is above it but can't log in.
+const (
+	firstUserUID = 1000
+	nobodyUID    = 65534
+)
+
+// Accounts lists the users with a regular UID or a login shell, their admin
+// group memberships and their authorized SSH keys
+func Accounts(root Root) ([]Account, error) {
+	entries, err := readColonFile(root, passwdPath, 7)
+	if err != nil {
+		return nil, err
+	}
+	groups, _ := readColonFile(root, groupPath, 4)
+	shells := loginShells(root)
+
+	// AuthorizedKeysFile tells where keys live; fall back to the default
+	keyFiles := []string{".ssh/authorized_keys", ".ssh/authorized_keys2"}
+	if cfg, err := ReadSSHDConfig(root); err == nil {
+		keyFiles = cfg.AuthorizedKeysFile
+	}
+
+	accounts := []Account{}
+	for _, e := range entries {
+		uid, err1 := strconv.Atoi(e[2])
+		gid, err2 := strconv.Atoi(e[3])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		a := Account{Name: e[0], UID: uid, GID: gid, Home: e[5], Shell: e[6]}
+		if !shells(a.Shell) && (uid < firstUserUID || uid == nobodyUID) {
+			continue
+		}
+
+		for _, g := range groups {
+			if !containsString(adminGroups, g[0]) {
+				continue
+			}
+			if g[2] == strconv.Itoa(gid) || containsString(strings.Split(g[3], ","), a.Name) {
+				a.AdminGroups = append(a.AdminGroups, g[0])
+			}
+		}
+		a.AuthorizedKeys = authorizedKeys(root, a, keyFiles)
+		accounts = append(accounts, a)
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].UID < accounts[j].UID })
+	return accounts, nil
+}
+
+// readColonFile parses a colon-separated database such as /etc/passwd,
+// skipping entries with fewer than n fields and NIS "+" lines
+func readColonFile(root Root, name string, n int) ([][]string, error) {
+	f, err := os.Open(root.Path(name))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries [][]string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-") {
+			continue
+		}
+		if fields := strings.Split(line, ":"); len(fields) >= n {
+			entries = append(entries, fields)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read /%s: %v", name, err)
+	}
+	return entries, nil
+}
+
+// loginShells returns a check for shells that allow interactive logins:
+// those listed in /etc/shells, or without it anything but nologin and false
+func loginShells(root Root) func(shell string) bool {
+	entries, err := readColonFile(root, shellsPath, 1)
+	if err != nil {
+		return func(shell string) bool {
+			base := path.Base(shell)
+			return shell != "" && base != "nologin" && base != "false"
+		}
+	}
+	listed := make(map[string]bool)
+	for _, e := range entries {
+		listed[e[0]] = true
+	}
+	return func(shell string) bool {
+		base := path.Base(shell)
+		return listed[shell] && base != "nologin" && base != "false"
+	}
+}
+
+// authorizedKeys reads the user's authorized_keys files. patterns come from
+// AuthorizedKeysFile and may use the %h, %u and %% tokens; relative ones are
+// below the home directory.
+func authorizedKeys(root Root, a Account, patterns []string) []AuthorizedKey {
+	var keys []AuthorizedKey
+	for _, pattern := range patterns {
+		if strings.ToLower(pattern) == "none" {
+			continue
+		}
+		file := strings.NewReplacer("%%", "%", "%h", a.Home, "%u", a.Name, "%U", strconv.Itoa(a.UID)).Replace(pattern)
+		if !strings.HasPrefix(file, "/") {
+			file = path.Join(a.Home, file)
+		}
+		data, err := os.ReadFile(root.Path(file))
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			if key, _, err := parseSSHPublicKey(line); err == nil {
+				keys = append(keys, AuthorizedKey{SSHKey: key, File: file})
+			}
+		}
+	}
+	return keys
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// NewAccountCollector reports who can log in locally and with which keys
+func NewAccountCollector(root Root) Collector {
+	return NewCollector("accounts", linuxOnly, func(ctx context.Context) (interface{}, error) {
+		return Accounts(root)
+	})
+}
@@ -0,0 +1,86 @@
+package inventory
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAccounts(t *testing.T) {
+	root := fixtureRoot(t, map[string]string{
+		"etc/passwd": "root:x:0:0:root:/root:/bin/bash\n" +
+			"daemon:x:1:1:daemon:/usr/sbin:/usr/sbin/nologin\n" +
+			"postgres:x:998:998::/var/lib/postgresql:/bin/zsh\n" +
+			"nobody:x:65534:65534:nobody:/nonexistent:/usr/sbin/nologin\n" +
+			"bob:x:1001:1001::/home/bob:/usr/sbin/nologin\n" +
+			"alice:x:1000:1000:Alice:/home/alice:/bin/bash\n" +
+			"broken:x:abc:1000::/home/broken:/bin/bash\n" +
+			"+::::::\n" +
+			"# comment\n",
+		"etc/group": "root:x:0:\n" +
+			"wheel:x:10:\n" +
+			"sudo:x:27:alice,carol\n" +
+			"users:x:100:alice,bob\n" +
+			"admin:x:1001:\n",
+		"etc/shells":          "# /etc/shells: valid login shells\n/bin/sh\n/bin/bash\n/usr/bin/bash\n",
+		"etc/ssh/sshd_config": "AuthorizedKeysFile .ssh/authorized_keys /etc/ssh/keys/%u\n",
+		"home/alice/.ssh/authorized_keys": "# alice's keys\n" +
+			testEd25519Key + " alice@laptop\n" +
+			"\n" +
+			`from="10.0.0.0/8",command="echo ssh-rsa nope" ` + testRSAKey + " backup\n" +
+			"not a key\n",
+		"home/bob/.ssh/authorized_keys2": testEd25519Key + "\n",
+		"etc/ssh/keys/root":              testECDSAKey + " deploy key\n",
+	})
+
+	want := []Account{
+		{Name: "root", UID: 0, GID: 0, Home: "/root", Shell: "/bin/bash", AuthorizedKeys: []AuthorizedKey{
+			{SSHKey: SSHKey{Type: "ecdsa-sha2-nistp256", Fingerprint: testECDSAFP, Comment: "deploy key"}, File: "/etc/ssh/keys/root"},
+		}},
+		{Name: "alice", UID: 1000, GID: 1000, Home: "/home/alice", Shell: "/bin/bash", AdminGroups: []string{"sudo"}, AuthorizedKeys: []AuthorizedKey{
+			{SSHKey: SSHKey{Type: "ssh-ed25519", Fingerprint: testEd25519FP, Comment: "alice@laptop"}, File: "/home/alice/.ssh/authorized_keys"},
+			{SSHKey: SSHKey{Type: "ssh-rsa", Fingerprint: testRSAFP, Comment: "backup"}, File: "/home/alice/.ssh/authorized_keys"},
+		}},
+		// A regular UID is listed even without a login shell; authorized_keys2
+		// is not read because AuthorizedKeysFile replaces the default
+		{Name: "bob", UID: 1001, GID: 1001, Home: "/home/bob", Shell: "/usr/sbin/nologin", AdminGroups: []string{"admin"}},
+	}
+	got, err := Accounts(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Accounts() = %+v, want %+v", got, want)
+	}
+}
+
+func TestAccountsDefaultKeyFiles(t *testing.T) {
+	// Without sshd_config or /etc/shells the OpenSSH defaults apply and any
+	// shell but nologin and false allows logins
+	root := fixtureRoot(t, map[string]string{
+		"etc/passwd":                    "svc:x:500:500::/srv/svc:/bin/zsh\nsync:x:4:65534:sync:/bin:/bin/false\n",
+		"srv/svc/.ssh/authorized_keys2": testEd25519Key + " svc\n",
+	})
+	want := []Account{
+		{Name: "svc", UID: 500, GID: 500, Home: "/srv/svc", Shell: "/bin/zsh", AuthorizedKeys: []AuthorizedKey{
+			{SSHKey: SSHKey{Type: "ssh-ed25519", Fingerprint: testEd25519FP, Comment: "svc"}, File: "/srv/svc/.ssh/authorized_keys2"},
+		}},
+	}
+	got, err := Accounts(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Accounts() = %+v, want %+v", got, want)
+	}
+}
+
+func TestAuthorizedKeysTokens(t *testing.T) {
+	root := fixtureRoot(t, map[string]string{
+		"keys/1000/alice%": testEd25519Key + "\n",
+	})
+	a := Account{Name: "alice", UID: 1000, Home: "/home/alice"}
+	got := authorizedKeys(root, a, []string{"none", "/keys/%U/%u%%"})
+	if len(got) != 1 || got[0].File != "/keys/1000/alice%" {
+		t.Errorf("authorizedKeys() = %+v, want one key from /keys/1000/alice%%", got)
+	}
+}
@@ -0,0 +1,261 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Collector gathers one section of the inventory
+type Collector interface {
+	// Name is the key the collected data is reported under
+	Name() string
+	// Supported reports whether the collector can run on the given GOOS
+	Supported(goos string) bool
+	// Collect gathers the data, giving up when ctx is done
+	Collect(ctx context.Context) (interface{}, error)
+}
+
+// ErrNotAvailable marks data whose source does not exist on this host, such
+// as DMI tables inside a container. Such results are reported as missing
+// rather than failed.
+var ErrNotAvailable = errors.New("not available on this host")
+
+// funcCollector adapts a plain function to the Collector interface
+type funcCollector struct {
+	name    string
+	goos    []string
+	collect func(ctx context.Context) (interface{}, error)
+}
+
+// NewCollector returns a Collector named name that runs collect on the
+// listed operating systems
+func NewCollector(name string, goos []string, collect func(ctx context.Context) (interface{}, error)) Collector {
+	return &funcCollector{name: name, goos: goos, collect: collect}
+}
+
+func (c *funcCollector) Name() string { return c.name }
+
+func (c *funcCollector) Supported(goos string) bool {
+	for _, g := range c.goos {
+		if g == goos {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *funcCollector) Collect(ctx context.Context) (interface{}, error) {
+	return c.collect(ctx)
+}
+
+// timeoutCollector overrides the registry timeout for one collector
+type timeoutCollector struct {
+	Collector
+	timeout time.Duration
+}
+
+// WithTimeout gives c its own deadline instead of the registry's, for
+// collectors that are slow by design such as passive listeners
+func WithTimeout(c Collector, timeout time.Duration) Collector {
+	return &timeoutCollector{Collector: c, timeout: timeout}
+}
+
+// linuxOnly is the OS list for collectors reading /proc and /sys
+var linuxOnly = []string{"linux"}
+
+// Status is the outcome of a single collector run
+type Status string
+
+const (
+	StatusOK      Status = "ok"
+	StatusMissing Status = "missing"
+	StatusFailed  Status = "failed"
+)
+
+// Result describes how a collector run went
+type Result struct {
+	Status     Status `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// Report is the outcome of a registry run: the collected data keyed by
+// collector name plus a result for every collector, so missing data can be
+// told apart from failed collection
+type Report struct {
+	Data    map[string]interface{} `json:"data"`
+	Results map[string]Result      `json:"results"`
+}
+
+// Committer is implemented by data that reports changes between runs.
+// Commit makes the data the baseline for the next run.
+type Committer interface {
+	Commit() error
+}
+
+// Commit commits the data of every collector that tracks changes. Call it
+// only after the report has been uploaded.
+func (r *Report) Commit() error {
+	var errs []string
+	for name, data := range r.Data {
+		if c, ok := data.(Committer); ok {
+			if err := c.Commit(); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("failed to save snapshots: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Missing returns the sorted names of collectors whose data is unavailable
+func (r *Report) Missing() []string {
+	return r.withStatus(StatusMissing)
+}
+
+// Failed returns the sorted names of collectors that failed or timed out
+func (r *Report) Failed() []string {
+	return r.withStatus(StatusFailed)
+}
+
+func (r *Report) withStatus(status Status) []string {
+	var names []string
+	for name, result := range r.Results {
+		if result.Status == status {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Registry runs a set of collectors in parallel
+type Registry struct {
+	// Timeout bounds each individual collector
+	Timeout time.Duration
+	// GOOS selects which collectors are supported
+	GOOS string
+
+	collectors []Collector
+}
+
+// NewRegistry returns an empty registry for goos with a per-collector timeout
+func NewRegistry(goos string, timeout time.Duration) *Registry {
+	return &Registry{GOOS: goos, Timeout: timeout}
+}
+
+// Register adds collectors. Several collectors may share a name as long as
+// they support different operating systems, e.g. a /proc based one for Linux
+// and a wmic based one for Windows.
+func (r *Registry) Register(collectors ...Collector) {
+	r.collectors = append(r.collectors, collectors...)
+}
+
+// selected returns, per name, the first registered collector supported on
+// r.GOOS, plus the names no collector supports
+func (r *Registry) selected() ([]Collector, []string) {
+	var chosen []Collector
+	var unsupported []string
+	seen := make(map[string]bool)
+	for _, c := range r.collectors {
+		if seen[c.Name()] || !c.Supported(r.GOOS) {
+			continue
+		}
+		seen[c.Name()] = true
+		chosen = append(chosen, c)
+	}
+	for _, c := range r.collectors {
+		if !seen[c.Name()] {
+			seen[c.Name()] = true
+			unsupported = append(unsupported, c.Name())
+		}
+	}
+	return chosen, unsupported
+}
+
+// Run executes every registered collector concurrently and waits for all of
+// them to finish or hit their deadline
+func (r *Registry) Run(ctx context.Context) *Report {
+	report := &Report{
+		Data:    make(map[string]interface{}),
+		Results: make(map[string]Result),
+	}
+
+	chosen, unsupported := r.selected()
+	for _, name := range unsupported {
+		report.Results[name] = Result{Status: StatusMissing, Error: fmt.Sprintf("not supported on %s", r.GOOS)}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, c := range chosen {
+		wg.Add(1)
+		go func(c Collector) {
+			defer wg.Done()
+			start := time.Now()
+			data, err := r.runOne(ctx, c)
+			result := Result{Status: StatusOK, DurationMS: time.Since(start).Milliseconds()}
+			switch {
+			case err == nil:
+			case errors.Is(err, ErrNotAvailable) || errors.Is(err, fs.ErrNotExist):
+				result.Status, result.Error = StatusMissing, err.Error()
+			default:
+				result.Status, result.Error = StatusFailed, err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Results[c.Name()] = result
+			if err == nil {
+				report.Data[c.Name()] = data
+			}
+		}(c)
+	}
+	wg.Wait()
+	return report
+}
+
+// runOne runs c under its own deadline. A collector stuck in a blocking read
+// (e.g. on a dead SAN path) is abandoned rather than waited for.
+func (r *Registry) runOne(ctx context.Context, c Collector) (interface{}, error) {
+	timeout := r.Timeout
+	if tc, ok := c.(*timeoutCollector); ok {
+		timeout = tc.timeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	type outcome struct {
+		data interface{}
+		err  error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- outcome{err: fmt.Errorf("collector panicked: %v", p)}
+			}
+		}()
+		data, err := c.Collect(ctx)
+		done <- outcome{data, err}
+	}()
+
+	select {
+	case o := <-done:
+		return o.data, o.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("timed out after %s: %v", timeout, ctx.Err())
+	}
+}
@@ -0,0 +1,289 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CPUModel returns the CPU model name from /proc/cpuinfo. ARM and other
+// architectures use different keys, so a few fallbacks are tried.
+func CPUModel(root Root) (string, error) {
+	info, err := root.readKeyValues(":", "proc", "cpuinfo")
+	if err != nil {
+		return "", err
+	}
+	for _, key := range []string{"model name", "Model", "Hardware", "cpu model", "Processor", "cpu"} {
+		if v := info[key]; v != "" {
+			return v, nil
+		}
+	}
+	return "", fmt.Errorf("no CPU model found in /proc/cpuinfo")
+}
+
+// NewCPUModelCollector reports the CPU model name
+func NewCPUModelCollector(root Root) Collector {
+	return NewCollector("cpu_model", linuxOnly, func(ctx context.Context) (interface{}, error) {
+		return CPUModel(root)
+	})
+}
+
+// CPUTopology describes the processors of the host
+type CPUTopology struct {
+	Model          string     `json:"model,omitempty"`
+	Vendor         string     `json:"vendor,omitempty"`
+	Sockets        int        `json:"sockets"`
+	Cores          int        `json:"cores"`
+	Threads        int        `json:"threads"`
+	NUMANodes      []NUMANode `json:"numa_nodes,omitempty"`
+	MinMHz         int        `json:"min_mhz,omitempty"`
+	MaxMHz         int        `json:"max_mhz,omitempty"`
+	Caches         []CPUCache `json:"caches,omitempty"`
+	Microcode      string     `json:"microcode,omitempty"`
+	Virtualization string     `json:"virtualization,omitempty"`
+	Flags          []string   `json:"flags,omitempty"`
+}
+
+// NUMANode is a NUMA node and the CPUs that belong to it
+type NUMANode struct {
+	ID      int    `json:"id"`
+	CPUList string `json:"cpu_list"`
+}
+
+// CPUCache is one cache level/type. Size is per instance; an L2 shared by
+// two threads on every core of a 16 core part has 16 instances.
+type CPUCache struct {
+	Level     int    `json:"level"`
+	Type      string `json:"type"`
+	Size      uint64 `json:"size"`
+	Instances int    `json:"instances"`
+}
+
+// interestingFlags are the feature flags worth reporting; the full list runs
+// to well over a hundred entries per CPU
+var interestingFlags = []string{
+	"vmx", "svm", "hypervisor", "aes", "sha_ni", "rdrand", "sse4_2",
+	"avx", "avx2", "avx512*", "avx_vnni", "amx*", "fma", "f16c",
+	// ARM
+	"sha2", "asimd", "sve", "sve2",
+}
+
+// CPU reads the processor topology from /proc/cpuinfo and
+// /sys/devices/system/cpu
+func CPU(root Root) (*CPUTopology, error) {
+	processors, err := readCPUInfo(root)
+	if err != nil {
+		return nil, err
+	}
+	if len(processors) == 0 {
+		return nil, fmt.Errorf("no processors found in /proc/cpuinfo")
+	}
+
+	topo := &CPUTopology{Threads: len(processors)}
+	first := processors[0]
+	topo.Model, _ = CPUModel(root)
+	topo.Vendor = first["vendor_id"]
+	topo.Microcode = first["microcode"]
+	if topo.Microcode == "" {
+		topo.Microcode, _ = root.ReadString("sys", "devices", "system", "cpu", "cpu0", "microcode", "version")
+	}
+
+	// Count sockets and cores from sysfs, falling back to the cpuinfo
+	// physical/core ids; without either every thread counts as a core
+	sockets := make(map[string]bool)
+	cores := make(map[string]bool)
+	for _, p := range processors {
+		cpuDir := []string{"sys", "devices", "system", "cpu", "cpu" + p["processor"], "topology"}
+		pkg, err := root.ReadString(append(cpuDir, "physical_package_id")...)
+		if err != nil {
+			pkg = p["physical id"]
+		}
+		core, err := root.ReadString(append(cpuDir, "core_id")...)
+		if err != nil {
+			core = p["core id"]
+		}
+		if core == "" {
+			core = "cpu" + p["processor"]
+		}
+		die, _ := root.ReadString(append(cpuDir, "die_id")...)
+		sockets[pkg] = true
+		cores[pkg+"/"+die+"/"+core] = true
+	}
+	topo.Sockets, topo.Cores = len(sockets), len(cores)
+
+	topo.NUMANodes, _ = numaNodes(root)
+	topo.MinMHz, topo.MaxMHz = cpuFrequency(root, processors)
+	topo.Caches = cpuCaches(root, processors)
+
+	flags := first["flags"]
+	if flags == "" {
+		flags = first["Features"]
+	}
+	topo.Flags = filterFlags(strings.Fields(flags))
+	for _, flag := range topo.Flags {
+		switch flag {
+		case "vmx":
+			topo.Virtualization = "VT-x"
+		case "svm":
+			topo.Virtualization = "AMD-V"
+		}
+	}
+	return topo, nil
+}
+
+// readCPUInfo splits /proc/cpuinfo into one key/value map per processor
+func readCPUInfo(root Root) ([]map[string]string, error) {
+	data, err := os.ReadFile(root.Path("proc", "cpuinfo"))
+	if err != nil {
+		return nil, err
+	}
+	var processors []map[string]string
+	for _, block := range strings.Split(string(data), "\n\n") {
+		values := make(map[string]string)
+		for _, line := range strings.Split(block, "\n") {
+			key, value, ok := strings.Cut(line, ":")
+			if ok {
+				values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+			}
+		}
+		if _, ok := values["processor"]; ok {
+			if _, err := strconv.Atoi(values["processor"]); err == nil {
+				processors = append(processors, values)
+			}
+		}
+	}
+	return processors, nil
+}
+
+// numaNodes lists the NUMA nodes in /sys/devices/system/node
+func numaNodes(root Root) ([]NUMANode, error) {
+	dirs, err := filepath.Glob(root.Path("sys", "devices", "system", "node", "node[0-9]*"))
+	if err != nil {
+		return nil, err
+	}
+	var nodes []NUMANode
+	for _, dir := range dirs {
+		id, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(dir), "node"))
+		if err != nil {
+			continue
+		}
+		cpus, _ := root.ReadString("sys", "devices", "system", "node", filepath.Base(dir), "cpulist")
+		nodes = append(nodes, NUMANode{ID: id, CPUList: cpus})
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	return nodes, nil
+}
+
+// cpuFrequency returns the lowest and highest frequency any CPU supports, in
+// MHz. VMs usually have no cpufreq driver, so the cpuinfo clock is the
+// fallback for both.
+func cpuFrequency(root Root, processors []map[string]string) (int, int) {
+	minKHz, maxKHz := uint64(0), uint64(0)
+	for _, p := range processors {
+		dir := []string{"sys", "devices", "system", "cpu", "cpu" + p["processor"], "cpufreq"}
+		if v, err := root.readUint(append(dir, "cpuinfo_min_freq")...); err == nil && (minKHz == 0 || v < minKHz) {
+			minKHz = v
+		}
+		if v, err := root.readUint(append(dir, "cpuinfo_max_freq")...); err == nil && v > maxKHz {
+			maxKHz = v
+		}
+	}
+	if maxKHz > 0 {
+		return int(minKHz / 1000), int(maxKHz / 1000)
+	}
+	if mhz, err := strconv.ParseFloat(processors[0]["cpu MHz"], 64); err == nil {
+		return int(mhz), int(mhz)
+	}
+	return 0, 0
+}
+
+// cpuCaches collects the caches of every CPU, counting each shared cache
+// once by its shared_cpu_list
+func cpuCaches(root Root, processors []map[string]string) []CPUCache {
+	type key struct {
+		level int
+		typ   string
+	}
+	caches := make(map[key]*CPUCache)
+	seen := make(map[string]bool)
+	for _, p := range processors {
+		cacheDir := []string{"sys", "devices", "system", "cpu", "cpu" + p["processor"], "cache"}
+		dirs, _ := filepath.Glob(root.Path(append(cacheDir, "index[0-9]*")...))
+		for _, dir := range dirs {
+			index := append(cacheDir, filepath.Base(dir))
+			level, err := root.readUint(append(index, "level")...)
+			if err != nil {
+				continue
+			}
+			typ, _ := root.ReadString(append(index, "type")...)
+			sizeStr, _ := root.ReadString(append(index, "size")...)
+			shared, _ := root.ReadString(append(index, "shared_cpu_list")...)
+			if shared == "" {
+				shared = p["processor"]
+			}
+
+			k := key{int(level), typ}
+			id := fmt.Sprintf("%d/%s/%s", level, typ, shared)
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			if caches[k] == nil {
+				caches[k] = &CPUCache{Level: k.level, Type: typ, Size: parseCacheSize(sizeStr)}
+			}
+			caches[k].Instances++
+		}
+	}
+
+	result := make([]CPUCache, 0, len(caches))
+	for _, c := range caches {
+		result = append(result, *c)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Level != result[j].Level {
+			return result[i].Level < result[j].Level
+		}
+		return result[i].Type < result[j].Type
+	})
+	return result
+}
+
+// parseCacheSize converts a sysfs cache size such as "48K" or "32M" to bytes
+func parseCacheSize(s string) uint64 {
+	multiplier := uint64(1)
+	switch {
+	case strings.HasSuffix(s, "K"):
+		multiplier, s = 1024, strings.TrimSuffix(s, "K")
+	case strings.HasSuffix(s, "M"):
+		multiplier, s = 1024*1024, strings.TrimSuffix(s, "M")
+	}
+	n, _ := strconv.ParseUint(s, 10, 64)
+	return n * multiplier
+}
+
+// filterFlags keeps the flags matching interestingFlags, sorted
+func filterFlags(flags []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, flag := range flags {
+		for _, pattern := range interestingFlags {
+			if ok, _ := filepath.Match(pattern, flag); ok && !seen[flag] {
+				seen[flag] = true
+				result = append(result, flag)
+			}
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// NewCPUCollector reports the processor topology
+func NewCPUCollector(root Root) Collector {
+	return NewCollector("cpu", linuxOnly, func(ctx context.Context) (interface{}, error) {
+		return CPU(root)
+	})
+}
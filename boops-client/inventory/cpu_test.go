@@ -0,0 +1,142 @@
+package inventory
+
+import (
+	"reflect"
+	"testing"
+)
+
+const x86CPUInfo = `processor	: 0
+vendor_id	: GenuineIntel
+model name	: Intel(R) Xeon(R) CPU E5-2680 v4 @ 2.40GHz
+physical id	: 0
+core id		: 0
+microcode	: 0xb000040
+flags		: fpu vme sse4_2 aes avx avx2 vmx hypervisor
+
+processor	: 1
+vendor_id	: GenuineIntel
+model name	: Intel(R) Xeon(R) CPU E5-2680 v4 @ 2.40GHz
+physical id	: 0
+core id		: 0
+microcode	: 0xb000040
+flags		: fpu vme sse4_2 aes avx avx2 vmx hypervisor
+
+processor	: 2
+vendor_id	: GenuineIntel
+model name	: Intel(R) Xeon(R) CPU E5-2680 v4 @ 2.40GHz
+physical id	: 1
+core id		: 0
+microcode	: 0xb000040
+flags		: fpu vme sse4_2 aes avx avx2 vmx hypervisor
+
+processor	: 3
+vendor_id	: GenuineIntel
+model name	: Intel(R) Xeon(R) CPU E5-2680 v4 @ 2.40GHz
+physical id	: 1
+core id		: 1
+microcode	: 0xb000040
+flags		: fpu vme sse4_2 aes avx avx2 vmx hypervisor
+`
+
+const armCPUInfo = `processor	: 0
+BogoMIPS	: 108.00
+Features	: fp asimd evtstrm aes pmull sha1 sha2 crc32 cpuid
+
+processor	: 1
+BogoMIPS	: 108.00
+Features	: fp asimd evtstrm aes pmull sha1 sha2 crc32 cpuid
+
+Hardware	: BCM2835
+Model		: Raspberry Pi 4 Model B Rev 1.4
+`
+
+func TestCPUModel(t *testing.T) {
+	tests := []struct {
+		name    string
+		cpuinfo string
+		want    string
+		wantErr bool
+	}{
+		{name: "x86", cpuinfo: x86CPUInfo, want: "Intel(R) Xeon(R) CPU E5-2680 v4 @ 2.40GHz"},
+		{name: "arm", cpuinfo: armCPUInfo, want: "Raspberry Pi 4 Model B Rev 1.4"},
+		{name: "unknown", cpuinfo: "processor\t: 0\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CPUModel(fixtureRoot(t, map[string]string{"proc/cpuinfo": tt.cpuinfo}))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CPUModel() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("CPUModel() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCPU(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		want    CPUTopology
+		wantErr bool
+	}{
+		{
+			name:  "cpuinfo ids",
+			files: map[string]string{"proc/cpuinfo": x86CPUInfo},
+			want: CPUTopology{
+				Model:          "Intel(R) Xeon(R) CPU E5-2680 v4 @ 2.40GHz",
+				Vendor:         "GenuineIntel",
+				Sockets:        2,
+				Cores:          3,
+				Threads:        4,
+				Microcode:      "0xb000040",
+				Virtualization: "VT-x",
+				Flags:          []string{"aes", "avx", "avx2", "hypervisor", "sse4_2", "vmx"},
+			},
+		},
+		{
+			name: "sysfs topology",
+			files: map[string]string{
+				"proc/cpuinfo": armCPUInfo,
+				"sys/devices/system/cpu/cpu0/topology/physical_package_id": "0\n",
+				"sys/devices/system/cpu/cpu0/topology/core_id":             "0\n",
+				"sys/devices/system/cpu/cpu1/topology/physical_package_id": "0\n",
+				"sys/devices/system/cpu/cpu1/topology/core_id":             "1\n",
+			},
+			want: CPUTopology{
+				Model:   "Raspberry Pi 4 Model B Rev 1.4",
+				Sockets: 1,
+				Cores:   2,
+				Threads: 2,
+				Flags:   []string{"aes", "asimd", "sha2"},
+			},
+		},
+		{
+			name:    "no processors",
+			files:   map[string]string{"proc/cpuinfo": "Hardware\t: BCM2835\n"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CPU(fixtureRoot(t, tt.files))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CPU() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			// the fixtures have no NUMA or cache entries in sysfs
+			if len(got.NUMANodes) == 0 {
+				got.NUMANodes = nil
+			}
+			if len(got.Caches) == 0 {
+				got.Caches = nil
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("CPU() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
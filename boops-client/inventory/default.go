@@ -0,0 +1,29 @@
+package inventory
+
+// Default returns the native collectors reading from root
+func Default(root Root) []Collector {
+	return []Collector{
+		NewHostnameCollector(root),
+		NewOSCollector(root),
+		NewCPUModelCollector(root),
+		NewCPUCollector(root),
+		NewMemoryCollector(root),
+		NewDiskCollector(root),
+		NewFilesystemCollector(root),
+		NewStorageCollector(root),
+		NewPCICollector(root),
+		NewUSBCollector(root),
+		NewPackageCollector(root),
+		NewSystemdCollector(root),
+		NewSocketCollector(root),
+		NewAccountCollector(root),
+		NewSSHDCollector(root),
+		NewHostKeyCollector(root),
+		NewNICCollector(root),
+		NewNetworkGraphCollector(root),
+		NewRouteCollector(root),
+		NewDNSCollector(root),
+		NewVirtualizationCollector(root),
+		NewDMICollector(root),
+	}
+}
@@ -0,0 +1,99 @@
+package inventory
+
+import "fmt"
+
+// smbiosTypeMemoryDevice is the SMBIOS memory device (DIMM slot) structure
+const smbiosTypeMemoryDevice = 17
+
+// DIMM is one memory slot from an SMBIOS type 17 record
+type DIMM struct {
+	Locator      string `json:"locator"`
+	Bank         string `json:"bank,omitempty"`
+	Populated    bool   `json:"populated"`
+	Size         uint64 `json:"size,omitempty"`
+	Type         string `json:"type,omitempty"`
+	FormFactor   string `json:"form_factor,omitempty"`
+	SpeedMTs     int    `json:"speed_mts,omitempty"`
+	Manufacturer string `json:"manufacturer,omitempty"`
+	PartNumber   string `json:"part_number,omitempty"`
+	SerialNumber string `json:"serial_number,omitempty"`
+}
+
+// memoryTypes maps SMBIOS memory type codes (SMBIOS spec 7.18.2) to names
+var memoryTypes = map[uint8]string{
+	0x01: "Other", 0x02: "Unknown", 0x03: "DRAM", 0x04: "EDRAM", 0x05: "VRAM",
+	0x06: "SRAM", 0x07: "RAM", 0x08: "ROM", 0x09: "Flash", 0x0a: "EEPROM",
+	0x0b: "FEPROM", 0x0c: "EPROM", 0x0d: "CDRAM", 0x0e: "3DRAM", 0x0f: "SDRAM",
+	0x10: "SGRAM", 0x11: "RDRAM", 0x12: "DDR", 0x13: "DDR2", 0x14: "DDR2 FB-DIMM",
+	0x18: "DDR3", 0x19: "FBD2", 0x1a: "DDR4", 0x1b: "LPDDR", 0x1c: "LPDDR2",
+	0x1d: "LPDDR3", 0x1e: "LPDDR4", 0x1f: "Logical non-volatile device",
+	0x20: "HBM", 0x21: "HBM2", 0x22: "DDR5", 0x23: "LPDDR5", 0x24: "HBM3",
+}
+
+// memoryFormFactors maps SMBIOS form factor codes (SMBIOS spec 7.18.1)
+var memoryFormFactors = map[uint8]string{
+	0x01: "Other", 0x02: "Unknown", 0x03: "SIMM", 0x04: "SIP", 0x05: "Chip",
+	0x06: "DIP", 0x07: "ZIP", 0x08: "Proprietary Card", 0x09: "DIMM",
+	0x0a: "TSOP", 0x0b: "Row of chips", 0x0c: "RIMM", 0x0d: "SODIMM",
+	0x0e: "SRIMM", 0x0f: "FB-DIMM", 0x10: "Die",
+}
+
+// DIMMs decodes every memory device record in table order, empty slots
+// included
+func DIMMs(structures []SMBIOSStructure) []DIMM {
+	var dimms []DIMM
+	for _, s := range smbiosOfType(structures, smbiosTypeMemoryDevice) {
+		dimm := DIMM{
+			Locator: s.String(0x10),
+			Bank:    s.String(0x11),
+			Size:    dimmSize(s),
+		}
+		if dimm.Locator == "" {
+			dimm.Locator = fmt.Sprintf("handle 0x%04x", s.Handle)
+		}
+		dimm.Populated = dimm.Size > 0
+		if !dimm.Populated {
+			dimms = append(dimms, dimm)
+			continue
+		}
+
+		if code, ok := s.Byte(0x12); ok {
+			dimm.Type = memoryTypes[code]
+		}
+		if code, ok := s.Byte(0x0e); ok {
+			dimm.FormFactor = memoryFormFactors[code]
+		}
+		if speed, ok := s.Word(0x15); ok && speed != 0 {
+			dimm.SpeedMTs = int(speed)
+			if speed == 0xffff { // SMBIOS 3.3+: the real value is the extended speed
+				extended, _ := s.DWord(0x54)
+				dimm.SpeedMTs = int(extended & 0x7fffffff)
+			}
+		}
+		dimm.Manufacturer = cleanSMBIOSString(s.String(0x17))
+		dimm.SerialNumber = cleanSMBIOSString(s.String(0x18))
+		dimm.PartNumber = cleanSMBIOSString(s.String(0x1a))
+		dimms = append(dimms, dimm)
+	}
+	return dimms
+}
+
+// dimmSize decodes the size of a memory device in bytes; 0 means the slot
+// is empty or the size is unknown
+func dimmSize(s SMBIOSStructure) uint64 {
+	size, ok := s.Word(0x0c)
+	switch {
+	case !ok, size == 0, size == 0xffff:
+		return 0
+	case size == 0x7fff: // 32GB or more: the size is in the extended size field, in MB
+		extended, ok := s.DWord(0x1c)
+		if !ok {
+			return 0
+		}
+		return uint64(extended&0x7fffffff) << 20
+	case size&0x8000 != 0: // Granularity bit set: KB
+		return uint64(size&0x7fff) << 10
+	default:
+		return uint64(size) << 20
+	}
+}
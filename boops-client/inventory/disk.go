@@ -0,0 +1,199 @@
+package inventory
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Disk is a whole block device found under /sys/block
+type Disk struct {
+	Name               string `json:"name"`
+	Size               uint64 `json:"size"`
+	Model              string `json:"model,omitempty"`
+	Serial             string `json:"serial,omitempty"`
+	WWN                string `json:"wwn,omitempty"`
+	Rotational         bool   `json:"rotational"`
+	Removable          bool   `json:"removable,omitempty"`
+	Transport          string `json:"transport,omitempty"`
+	LogicalSectorSize  uint64 `json:"logical_sector_size,omitempty"`
+	PhysicalSectorSize uint64 `json:"physical_sector_size,omitempty"`
+	PartitionTable     string `json:"partition_table,omitempty"`
+}
+
+// ignoredBlockDevices are virtual devices that never hold data worth
+// inventorying
+var ignoredBlockDevices = []string{"loop", "ram", "zram"}
+
+// BlockDevices lists the disks in /sys/block, skipping loop, ram and zram
+// devices. udev's database supplies what sysfs lacks for some drivers.
+func BlockDevices(root Root) ([]Disk, error) {
+	entries, err := os.ReadDir(root.Path("sys", "block"))
+	if err != nil {
+		return nil, err
+	}
+
+	var disks []Disk
+	for _, entry := range entries {
+		if ignoredBlockDevice(entry.Name()) {
+			continue
+		}
+		disk, err := blockDevice(root, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		disks = append(disks, disk)
+	}
+	sort.Slice(disks, func(i, j int) bool { return disks[i].Name < disks[j].Name })
+	return disks, nil
+}
+
+func ignoredBlockDevice(name string) bool {
+	for _, prefix := range ignoredBlockDevices {
+		if rest := strings.TrimPrefix(name, prefix); rest != name && rest != "" && strings.Trim(rest, "0123456789") == "" {
+			return true
+		}
+	}
+	return false
+}
+
+func blockDevice(root Root, name string) (Disk, error) {
+	dir := []string{"sys", "block", name}
+	attr := func(elem ...string) string {
+		v, _ := root.ReadString(append(append([]string{}, dir...), elem...)...)
+		return v
+	}
+
+	// The size attribute is always counted in 512-byte sectors
+	sectors, err := root.readUint(append(dir, "size")...)
+	if err != nil {
+		return Disk{}, err
+	}
+	disk := Disk{
+		Name:       name,
+		Size:       sectors * 512,
+		Model:      attr("device", "model"),
+		Serial:     attr("device", "serial"),
+		WWN:        attr("device", "wwid"),
+		Rotational: attr("queue", "rotational") == "1",
+		Removable:  attr("removable") == "1",
+	}
+	if disk.Serial == "" {
+		disk.Serial = attr("serial") // virtio
+	}
+	if disk.WWN == "" {
+		disk.WWN = attr("wwid") // NVMe namespaces
+	}
+	disk.LogicalSectorSize, _ = root.readUint(append(dir, "queue", "logical_block_size")...)
+	disk.PhysicalSectorSize, _ = root.readUint(append(dir, "queue", "physical_block_size")...)
+
+	udev := udevProperties(root, attr("dev"))
+	if disk.Model == "" {
+		disk.Model = strings.ReplaceAll(udev["ID_MODEL"], "_", " ")
+	}
+	if v := udev["ID_SERIAL_SHORT"]; v != "" {
+		disk.Serial = v // sysfs only has the serial for NVMe and some SCSI devices
+	}
+	if v := udev["ID_WWN_WITH_EXTENSION"]; v != "" {
+		disk.WWN = v
+	} else if v := udev["ID_WWN"]; v != "" {
+		disk.WWN = v
+	}
+	disk.Model = strings.TrimSpace(disk.Model)
+	disk.Transport = blockTransport(root, name, udev)
+	disk.PartitionTable = udev["ID_PART_TABLE_TYPE"]
+	if disk.PartitionTable == "" {
+		disk.PartitionTable = readPartitionTable(root.Path("dev", name))
+	}
+	return disk, nil
+}
+
+// blockTransport derives the bus a disk is attached through from its sysfs
+// device path
+func blockTransport(root Root, name string, udev map[string]string) string {
+	path, err := filepath.EvalSymlinks(root.Path("sys", "block", name))
+	if err != nil {
+		path = ""
+	}
+	switch {
+	case strings.HasPrefix(name, "nvme"):
+		return "NVMe"
+	case strings.Contains(path, "/virtio"):
+		return "virtio"
+	case strings.Contains(path, "/usb"), udev["ID_BUS"] == "usb":
+		return "USB"
+	case strings.HasPrefix(name, "mmcblk"):
+		return "MMC"
+	case strings.HasPrefix(name, "xvd"):
+		return "Xen"
+	case strings.Contains(path, "/ata"), udev["ID_BUS"] == "ata":
+		return "SATA"
+	case root.Exists("sys", "block", name, "device", "sas_address"):
+		return "SAS"
+	case udev["ID_BUS"] == "scsi", strings.Contains(path, "/host"):
+		return "SCSI"
+	}
+	return ""
+}
+
+// udevProperties reads the udev database entry of the device with the given
+// major:minor number
+func udevProperties(root Root, dev string) map[string]string {
+	props := make(map[string]string)
+	if dev == "" {
+		return props
+	}
+	f, err := os.Open(root.Path("run", "udev", "data", "b"+dev))
+	if err != nil {
+		return props
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "E:") {
+			continue
+		}
+		if key, value, ok := strings.Cut(line[2:], "="); ok {
+			props[key] = value
+		}
+	}
+	return props
+}
+
+// readPartitionTable identifies the partition table from the MBR, where a
+// protective 0xEE partition marks GPT. It returns "" when the device can't
+// be read or holds no partition table.
+func readPartitionTable(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	mbr := make([]byte, 512)
+	if _, err := f.ReadAt(mbr, 0); err != nil {
+		return ""
+	}
+	if binary.LittleEndian.Uint16(mbr[510:]) != 0xaa55 {
+		return ""
+	}
+	for i := 0; i < 4; i++ {
+		if mbr[446+i*16+4] == 0xee {
+			return "gpt"
+		}
+	}
+	return "dos"
+}
+
+// NewDiskCollector reports the block devices
+func NewDiskCollector(root Root) Collector {
+	return NewCollector("disks", linuxOnly, func(ctx context.Context) (interface{}, error) {
+		return BlockDevices(root)
+	})
+}
@@ -0,0 +1,119 @@
+package inventory
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// mbr returns a boot sector whose first partition has the given type
+func mbr(partitionType byte) string {
+	b := make([]byte, 512)
+	b[446+4] = partitionType
+	b[510], b[511] = 0x55, 0xaa
+	return string(b)
+}
+
+func TestBlockDevices(t *testing.T) {
+	// Each disk lives at its device path; /sys/block only links to it
+	devices := map[string]string{
+		"sda":     "devices/pci0000:00/0000:00:17.0/ata1/host0/target0:0:0/0:0:0:0/block/sda",
+		"sdb":     "devices/pci0000:00/0000:00:14.0/usb2/2-1/2-1:1.0/host6/target6:0:0/6:0:0:0/block/sdb",
+		"sdc":     "devices/pci0000:00/0000:03:00.0/host2/port-2:0/end_device-2:0/target2:0:0/2:0:0:0/block/sdc",
+		"nvme0n1": "devices/pci0000:00/0000:01:00.0/nvme/nvme0/nvme0n1",
+		"vda":     "devices/pci0000:00/0000:00:04.0/virtio1/block/vda",
+		"loop0":   "devices/virtual/block/loop0",
+		"ram1":    "devices/virtual/block/ram1",
+		"zram0":   "devices/virtual/block/zram0",
+	}
+	files := map[string]string{
+		"run/udev/data/b8:0": "S:disk/by-id/ata-Samsung_SSD_860_EVO_500GB_S3Z1NB0K\n" +
+			"E:ID_BUS=ata\nE:ID_MODEL=Samsung_SSD_860_EVO_500GB\nE:ID_SERIAL_SHORT=S3Z1NB0K\n" +
+			"E:ID_WWN=0x5002538e40a1b2c3\nE:ID_WWN_WITH_EXTENSION=0x5002538e40a1b2c3\nE:ID_PART_TABLE_TYPE=gpt\n",
+		"run/udev/data/b8:16": "E:ID_BUS=usb\nE:ID_MODEL=Flash_Disk\n",
+		"dev/nvme0n1":         mbr(0xee),
+		"dev/vda":             mbr(0x83),
+		"dev/sdc":             strings.Repeat("\x00", 512),
+	}
+	sysfs := map[string]map[string]string{
+		"sda": {"size": "976773168", "dev": "8:0", "queue/rotational": "0",
+			"queue/logical_block_size": "512", "queue/physical_block_size": "4096"},
+		"sdb": {"size": "31266816", "dev": "8:16", "removable": "1", "queue/rotational": "1",
+			"device/model": "Flash Disk      "},
+		"sdc": {"size": "35156656", "dev": "8:32", "queue/rotational": "1",
+			"device/model": "ST18000NM004J", "device/sas_address": "0x5000c500d1e2f3a4"},
+		"nvme0n1": {"size": "1953525168", "dev": "259:0", "queue/rotational": "0",
+			"device/model": "Samsung SSD 980 PRO 1TB", "device/serial": "S5GXNF0R",
+			"wwid": "eui.002538b111b2c3d4"},
+		"vda":   {"size": "41943040", "dev": "252:0", "serial": "vm-disk-1"},
+		"loop0": {"size": "0"},
+		"ram1":  {"size": "8192"},
+		"zram0": {"size": "4194304"},
+	}
+	for name, attrs := range sysfs {
+		for attr, value := range attrs {
+			files["sys/"+devices[name]+"/"+attr] = value + "\n"
+		}
+	}
+	root := fixtureRoot(t, files)
+	if err := os.MkdirAll(root.Path("sys", "block"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, path := range devices {
+		if err := os.Symlink("../"+path, root.Path("sys", "block", name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []Disk{
+		{Name: "nvme0n1", Size: 1953525168 * 512, Model: "Samsung SSD 980 PRO 1TB", Serial: "S5GXNF0R",
+			WWN: "eui.002538b111b2c3d4", Transport: "NVMe", PartitionTable: "gpt"},
+		{Name: "sda", Size: 976773168 * 512, Model: "Samsung SSD 860 EVO 500GB", Serial: "S3Z1NB0K",
+			WWN: "0x5002538e40a1b2c3", Transport: "SATA", LogicalSectorSize: 512, PhysicalSectorSize: 4096,
+			PartitionTable: "gpt"},
+		{Name: "sdb", Size: 31266816 * 512, Model: "Flash Disk", Rotational: true, Removable: true, Transport: "USB"},
+		{Name: "sdc", Size: 35156656 * 512, Model: "ST18000NM004J", Rotational: true, Transport: "SAS"},
+		{Name: "vda", Size: 41943040 * 512, Serial: "vm-disk-1", Transport: "virtio", PartitionTable: "dos"},
+	}
+	got, err := BlockDevices(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("BlockDevices() = %+v, want %+v", got, want)
+	}
+}
+
+func TestIgnoredBlockDevice(t *testing.T) {
+	tests := map[string]bool{
+		"loop0":    true,
+		"loop12":   true,
+		"ram0":     true,
+		"zram1":    true,
+		"loop":     false,
+		"ramdisk0": false,
+		"sda":      false,
+		"nvme0n1":  false,
+	}
+	for name, want := range tests {
+		if got := ignoredBlockDevice(name); got != want {
+			t.Errorf("ignoredBlockDevice(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestReadPartitionTable(t *testing.T) {
+	root := fixtureRoot(t, map[string]string{
+		"gpt":   mbr(0xee),
+		"dos":   mbr(0x83),
+		"empty": strings.Repeat("\x00", 512),
+		"short": "\x55\xaa",
+	})
+	tests := map[string]string{"gpt": "gpt", "dos": "dos", "empty": "", "short": "", "missing": ""}
+	for name, want := range tests {
+		if got := readPartitionTable(root.Path(name)); got != want {
+			t.Errorf("readPartitionTable(%s) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// ModelInfo is the hardware identity of the machine as reported by DMI/SMBIOS
+type ModelInfo struct {
+	SystemVendor   string `json:"system_vendor,omitempty"`
+	ProductName    string `json:"product_name,omitempty"`
+	ProductVersion string `json:"product_version,omitempty"`
+	SerialNumber   string `json:"serial_number,omitempty"`
+	AssetTag       string `json:"asset_tag,omitempty"`
+	ChassisType    string `json:"chassis_type,omitempty"`
+	BoardVendor    string `json:"board_vendor,omitempty"`
+	BoardName      string `json:"board_name,omitempty"`
+	BoardSerial    string `json:"board_serial,omitempty"`
+	SystemUUID     string `json:"system_uuid,omitempty"`
+	BIOSVendor     string `json:"bios_vendor,omitempty"`
+	BIOSVersion    string `json:"bios_version,omitempty"`
+}
+
+// chassisTypes maps SMBIOS chassis type codes (SMBIOS spec 7.4.1) to names
+var chassisTypes = map[int]string{
+	1: "Other", 2: "Unknown", 3: "Desktop", 4: "Low Profile Desktop",
+	5: "Pizza Box", 6: "Mini Tower", 7: "Tower", 8: "Portable", 9: "Laptop",
+	10: "Notebook", 11: "Hand Held", 12: "Docking Station", 13: "All in One",
+	14: "Sub Notebook", 15: "Space-saving", 16: "Lunch Box",
+	17: "Main Server Chassis", 18: "Expansion Chassis", 19: "SubChassis",
+	20: "Bus Expansion Chassis", 21: "Peripheral Chassis", 22: "RAID Chassis",
+	23: "Rack Mount Chassis", 24: "Sealed-case PC", 25: "Multi-system Chassis",
+	26: "Compact PCI", 27: "Advanced TCA", 28: "Blade", 29: "Blade Enclosure",
+	30: "Tablet", 31: "Convertible", 32: "Detachable", 33: "IoT Gateway",
+	34: "Embedded PC", 35: "Mini PC", 36: "Stick PC",
+}
+
+func chassisTypeName(code int) string {
+	if name, ok := chassisTypes[code]; ok {
+		return name
+	}
+	return fmt.Sprintf("Unknown (%d)", code)
+}
+
+// DMI reads the hardware identity from /sys/class/dmi/id and fills any gaps
+// from the raw SMBIOS tables in /sys/firmware/dmi/tables
+func DMI(root Root) (*ModelInfo, error) {
+	info := &ModelInfo{}
+	found := false
+
+	read := func(attr string) string {
+		v, err := root.ReadString("sys", "class", "dmi", "id", attr)
+		if err != nil {
+			return ""
+		}
+		found = true
+		return cleanSMBIOSString(v)
+	}
+	info.SystemVendor = read("sys_vendor")
+	info.ProductName = read("product_name")
+	info.ProductVersion = read("product_version")
+	info.SerialNumber = read("product_serial")
+	info.AssetTag = read("chassis_asset_tag")
+	info.BoardVendor = read("board_vendor")
+	info.BoardName = read("board_name")
+	info.BoardSerial = read("board_serial")
+	info.SystemUUID = read("product_uuid")
+	info.BIOSVendor = read("bios_vendor")
+	info.BIOSVersion = read("bios_version")
+	if code, err := strconv.Atoi(read("chassis_type")); err == nil {
+		info.ChassisType = chassisTypeName(code)
+	}
+
+	// Serial numbers and the UUID are only readable by root in sysfs, and
+	// some kernels omit attributes, so the raw tables fill the gaps
+	if structures, err := ReadSMBIOS(root); err == nil {
+		found = true
+		fillFromSMBIOS(info, structures)
+	}
+
+	if !found {
+		return nil, fmt.Errorf("DMI: %w", ErrNotAvailable)
+	}
+	return info, nil
+}
+
+// fillFromSMBIOS sets every empty field of info from SMBIOS types 0-3
+func fillFromSMBIOS(info *ModelInfo, structures []SMBIOSStructure) {
+	fill := func(dst *string, value string) {
+		if *dst == "" {
+			*dst = cleanSMBIOSString(value)
+		}
+	}
+
+	for _, s := range smbiosOfType(structures, 0) { // BIOS information
+		fill(&info.BIOSVendor, s.String(0x04))
+		fill(&info.BIOSVersion, s.String(0x05))
+	}
+	for _, s := range smbiosOfType(structures, smbiosTypeSystem) {
+		fill(&info.SystemVendor, s.String(0x04))
+		fill(&info.ProductName, s.String(0x05))
+		fill(&info.ProductVersion, s.String(0x06))
+		fill(&info.SerialNumber, s.String(0x07))
+		if len(s.Formatted) >= 0x18 {
+			fill(&info.SystemUUID, smbiosUUID(s.Formatted[0x08:0x18]))
+		}
+	}
+	for _, s := range smbiosOfType(structures, smbiosTypeBoard) {
+		fill(&info.BoardVendor, s.String(0x04))
+		fill(&info.BoardName, s.String(0x05))
+		fill(&info.BoardSerial, s.String(0x07))
+	}
+	for _, s := range smbiosOfType(structures, smbiosTypeChassis) {
+		fill(&info.AssetTag, s.String(0x08))
+		if code, ok := s.Byte(0x05); ok && info.ChassisType == "" {
+			info.ChassisType = chassisTypeName(int(code & 0x7f)) // Bit 7 is the lock flag
+		}
+	}
+}
+
+// smbiosUUID formats the 16 UUID bytes of a type 1 structure. Since SMBIOS
+// 2.6 the first three fields are little-endian, which is what every system
+// still in service uses.
+func smbiosUUID(b []byte) string {
+	allZero, allOnes := true, true
+	for _, v := range b {
+		allZero = allZero && v == 0x00
+		allOnes = allOnes && v == 0xff
+	}
+	if allZero || allOnes {
+		return "" // Not present / not settable
+	}
+	return fmt.Sprintf("%02x%02x%02x%02x-%02x%02x-%02x%02x-%02x%02x-%02x%02x%02x%02x%02x%02x",
+		b[3], b[2], b[1], b[0], b[5], b[4], b[7], b[6],
+		b[8], b[9], b[10], b[11], b[12], b[13], b[14], b[15])
+}
+
+// NewDMICollector reports the DMI/SMBIOS hardware identity
+func NewDMICollector(root Root) Collector {
+	return NewCollector("dmi", linuxOnly, func(ctx context.Context) (interface{}, error) {
+		return DMI(root)
+	})
+}
@@ -0,0 +1,142 @@
+package inventory
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"os"
+	"strings"
+)
+
+// Resolvers are the DNS servers the host actually uses
+type Resolvers struct {
+	// Global servers apply to every interface without servers of its own
+	Global []string `json:"global,omitempty"`
+	// PerLink holds the servers systemd-resolved uses for each interface
+	PerLink map[string][]string `json:"per_link,omitempty"`
+	Search  []string            `json:"search,omitempty"`
+}
+
+// For returns the servers used for lookups through iface: its own servers
+// when systemd-resolved has any, otherwise the global ones
+func (r *Resolvers) For(iface string) []string {
+	if servers := r.PerLink[iface]; len(servers) > 0 {
+		return servers
+	}
+	return r.Global
+}
+
+// DNSResolvers reads the effective resolvers. When resolv.conf points at
+// the systemd-resolved stub, the upstream servers are read from resolved
+// instead, since 127.0.0.53 says nothing about the network.
+func DNSResolvers(ctx context.Context, root Root) (*Resolvers, error) {
+	servers, search, err := readResolvConf(root.Path("etc", "resolv.conf"))
+	if err != nil {
+		return nil, err
+	}
+	resolvers := &Resolvers{Global: servers, Search: search}
+	if !onlyResolvedStub(servers) {
+		return resolvers, nil
+	}
+
+	if upstream, _, err := readResolvConf(root.Path("run", "systemd", "resolve", "resolv.conf")); err == nil {
+		resolvers.Global = upstream
+	}
+	if root.live() {
+		if output, err := runCommand(ctx, "resolvectl", "dns"); err == nil {
+			global, perLink := parseResolvectlDNS(string(output))
+			if len(global) > 0 {
+				resolvers.Global = global
+			}
+			resolvers.PerLink = perLink
+		}
+	}
+	return resolvers, nil
+}
+
+// readResolvConf returns the nameserver and search entries of a resolv.conf
+func readResolvConf(path string) ([]string, []string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	var servers, search []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "nameserver":
+			servers = append(servers, fields[1])
+		case "search", "domain":
+			search = append(search, fields[1:]...)
+		}
+	}
+	return servers, search, scanner.Err()
+}
+
+func onlyResolvedStub(servers []string) bool {
+	for _, s := range servers {
+		if s != "127.0.0.53" && s != "127.0.0.54" {
+			return false
+		}
+	}
+	return len(servers) > 0
+}
+
+// parseResolvectlDNS parses "resolvectl dns" output:
+//
+//	Global: 1.1.1.1#cloudflare-dns.com
+//	Link 2 (eth0): 192.168.1.1 fe80::1%2
+func parseResolvectlDNS(output string) ([]string, map[string][]string) {
+	var global []string
+	perLink := make(map[string][]string)
+	for _, line := range strings.Split(output, "\n") {
+		label, list, ok := strings.Cut(line, ":")
+		if strings.HasPrefix(strings.TrimSpace(line), "Link ") {
+			// The interface name is in parentheses before the colon
+			start, end := strings.Index(line, "("), strings.Index(line, "):")
+			if start < 0 || end < start {
+				continue
+			}
+			label, list, ok = line[start+1:end], line[end+2:], true
+		}
+		if !ok {
+			continue
+		}
+		var servers []string
+		for _, s := range strings.Fields(list) {
+			if s = cleanDNSServer(s); s != "" {
+				servers = append(servers, s)
+			}
+		}
+		if strings.TrimSpace(label) == "Global" {
+			global = servers
+		} else if len(servers) > 0 {
+			perLink[strings.TrimSpace(label)] = servers
+		}
+	}
+	return global, perLink
+}
+
+// cleanDNSServer strips the "#server-name" and "%zone" suffixes resolved
+// prints and drops anything that isn't an IP address
+func cleanDNSServer(s string) string {
+	s, _, _ = strings.Cut(s, "#")
+	s, _, _ = strings.Cut(s, "%")
+	if net.ParseIP(s) == nil {
+		return ""
+	}
+	return s
+}
+
+// NewDNSCollector reports the effective DNS resolvers
+func NewDNSCollector(root Root) Collector {
+	return NewCollector("dns", linuxOnly, func(ctx context.Context) (interface{}, error) {
+		return DNSResolvers(ctx, root)
+	})
+}
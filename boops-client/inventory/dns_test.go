@@ -0,0 +1,79 @@
+package inventory
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestParseResolvectlDNS(t *testing.T) {
+	output := "Global: 1.1.1.1#cloudflare-dns.com 9.9.9.9\n" +
+		"Link 2 (eth0): 192.168.1.1 fe80::1%2\n" +
+		"Link 3 (wlan0):\n" +
+		"Link 4 (wg0): 10.8.0.1 bogus\n"
+	global, perLink := parseResolvectlDNS(output)
+	if want := []string{"1.1.1.1", "9.9.9.9"}; !reflect.DeepEqual(global, want) {
+		t.Errorf("parseResolvectlDNS() global = %q, want %q", global, want)
+	}
+	wantLinks := map[string][]string{
+		"eth0": {"192.168.1.1", "fe80::1"},
+		"wg0":  {"10.8.0.1"},
+	}
+	if !reflect.DeepEqual(perLink, wantLinks) {
+		t.Errorf("parseResolvectlDNS() per link = %q, want %q", perLink, wantLinks)
+	}
+
+	r := &Resolvers{Global: global, PerLink: perLink}
+	if got := r.For("eth0"); !reflect.DeepEqual(got, wantLinks["eth0"]) {
+		t.Errorf("For(eth0) = %q, want its own servers", got)
+	}
+	if got := r.For("wlan0"); !reflect.DeepEqual(got, global) {
+		t.Errorf("For(wlan0) = %q, want the global servers", got)
+	}
+}
+
+func TestDNSResolvers(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		want    *Resolvers
+		wantErr bool
+	}{
+		{
+			name: "resolv.conf",
+			files: map[string]string{
+				"etc/resolv.conf": "# generated\nnameserver 10.0.0.53\nnameserver 10.0.0.54\nsearch example.com corp.example.com\noptions edns0\nnameserver\n",
+			},
+			want: &Resolvers{Global: []string{"10.0.0.53", "10.0.0.54"}, Search: []string{"example.com", "corp.example.com"}},
+		},
+		{
+			name: "systemd-resolved stub",
+			files: map[string]string{
+				"etc/resolv.conf":                 "nameserver 127.0.0.53\noptions edns0 trust-ad\nsearch example.com\n",
+				"run/systemd/resolve/resolv.conf": "nameserver 192.168.1.1\nnameserver 192.168.1.2\n",
+			},
+			want: &Resolvers{Global: []string{"192.168.1.1", "192.168.1.2"}, Search: []string{"example.com"}},
+		},
+		{
+			name:  "stub without upstream file",
+			files: map[string]string{"etc/resolv.conf": "nameserver 127.0.0.53\n"},
+			want:  &Resolvers{Global: []string{"127.0.0.53"}},
+		},
+		{
+			name:    "missing",
+			files:   map[string]string{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DNSResolvers(context.Background(), fixtureRoot(t, tt.files))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DNSResolvers() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DNSResolvers() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
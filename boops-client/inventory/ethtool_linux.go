@@ -0,0 +1,96 @@
+package inventory
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	siocEthtool        = 0x8946
+	ethtoolGDrvInfo    = 0x00000003
+	ethtoolGPermAddr   = 0x00000020
+	ethtoolMaxAddrSize = 32
+)
+
+// ifreq is struct ifreq with the ifr_data member of the union
+type ifreq struct {
+	name [syscall.IFNAMSIZ]byte
+	data unsafe.Pointer
+	_    [16]byte
+}
+
+// ethtoolDrvInfo is struct ethtool_drvinfo
+type ethtoolDrvInfo struct {
+	cmd         uint32
+	driver      [32]byte
+	version     [32]byte
+	fwVersion   [32]byte
+	busInfo     [32]byte
+	eromVersion [32]byte
+	reserved2   [12]byte
+	nPrivFlags  uint32
+	nStats      uint32
+	testinfoLen uint32
+	eedumpLen   uint32
+	regdumpLen  uint32
+}
+
+// ethtoolPermAddr is struct ethtool_perm_addr with room for the address
+type ethtoolPermAddr struct {
+	cmd  uint32
+	size uint32
+	data [ethtoolMaxAddrSize]byte
+}
+
+// ethtool issues a SIOCETHTOOL ioctl for iface with data as ifr_data
+func ethtool(iface string, data unsafe.Pointer) error {
+	if len(iface) >= syscall.IFNAMSIZ {
+		return fmt.Errorf("interface name too long: %s", iface)
+	}
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	defer syscall.Close(fd)
+
+	req := ifreq{data: data}
+	copy(req.name[:], iface)
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), siocEthtool, uintptr(unsafe.Pointer(&req))); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func ethtoolDriverInfo(iface string) (ethtoolInfo, error) {
+	info := &ethtoolDrvInfo{cmd: ethtoolGDrvInfo}
+	if err := ethtool(iface, unsafe.Pointer(info)); err != nil {
+		return ethtoolInfo{}, err
+	}
+	return ethtoolInfo{
+		Driver:   cString(info.driver[:]),
+		Version:  cString(info.version[:]),
+		Firmware: cString(info.fwVersion[:]),
+	}, nil
+}
+
+func ethtoolPermanentAddr(iface string) (string, error) {
+	addr := &ethtoolPermAddr{cmd: ethtoolGPermAddr, size: ethtoolMaxAddrSize}
+	if err := ethtool(iface, unsafe.Pointer(addr)); err != nil {
+		return "", err
+	}
+	if addr.size == 0 || addr.size > ethtoolMaxAddrSize || bytes.Count(addr.data[:addr.size], []byte{0}) == int(addr.size) {
+		return "", fmt.Errorf("no permanent address for %s", iface)
+	}
+	return net.HardwareAddr(addr.data[:addr.size]).String(), nil
+}
+
+// cString converts a NUL-terminated byte array to a string
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
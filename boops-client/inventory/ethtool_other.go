@@ -0,0 +1,15 @@
+//go:build !linux
+
+package inventory
+
+import "fmt"
+
+// The ethtool ioctl only exists on Linux
+
+func ethtoolDriverInfo(iface string) (ethtoolInfo, error) {
+	return ethtoolInfo{}, fmt.Errorf("ethtool: %w", ErrNotAvailable)
+}
+
+func ethtoolPermanentAddr(iface string) (string, error) {
+	return "", fmt.Errorf("ethtool: %w", ErrNotAvailable)
+}
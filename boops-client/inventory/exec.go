@@ -0,0 +1,26 @@
+package inventory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// runCommand runs name with args as an argv vector and returns its standard
+// output. A missing binary is reported as ErrNotAvailable.
+func runCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, ErrNotAvailable)
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return output, fmt.Errorf("%s failed with error: %v, output: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return output, nil
+}
@@ -0,0 +1,176 @@
+package inventory
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filesystem is a mounted filesystem with its space and inode usage
+type Filesystem struct {
+	Device      string  `json:"device"`
+	Mountpoint  string  `json:"mountpoint"`
+	Type        string  `json:"type"`
+	ReadOnly    bool    `json:"read_only,omitempty"`
+	Total       uint64  `json:"total_bytes"`
+	Used        uint64  `json:"used_bytes"`
+	Free        uint64  `json:"free_bytes"`
+	UsedPercent float64 `json:"used_percent"`
+	Inodes      uint64  `json:"inodes,omitempty"`
+	InodesUsed  uint64  `json:"inodes_used,omitempty"`
+	InodesFree  uint64  `json:"inodes_free,omitempty"`
+	// Unresponsive marks a mount whose statfs didn't return in time, such
+	// as a hard NFS mount whose server is gone; its usage is unknown
+	Unresponsive bool `json:"unresponsive,omitempty"`
+}
+
+// statTimeout bounds statfs on a single mount. It blocks for as long as a
+// network filesystem's server is unreachable.
+var statTimeout = 2 * time.Second
+
+// pseudoFilesystems are kernel and virtual filesystems that hold no data
+// worth monitoring. squashfs images (snaps) are always 100% full by design.
+var pseudoFilesystems = map[string]bool{
+	"proc": true, "sysfs": true, "devtmpfs": true, "devpts": true, "tmpfs": true,
+	"securityfs": true, "cgroup": true, "cgroup2": true, "pstore": true,
+	"bpf": true, "debugfs": true, "tracefs": true, "configfs": true,
+	"fusectl": true, "mqueue": true, "hugetlbfs": true, "autofs": true,
+	"binfmt_misc": true, "rpc_pipefs": true, "nsfs": true, "efivarfs": true,
+	"selinuxfs": true, "squashfs": true, "ramfs": true, "fuse.lxcfs": true,
+	"fuse.gvfsd-fuse": true, "fuse.portal": true,
+}
+
+// Mount is one entry of /proc/self/mountinfo
+type Mount struct {
+	DeviceID   string
+	Mountpoint string
+	Type       string
+	Source     string
+	Options    []string
+}
+
+// Mounts parses /proc/self/mountinfo
+func Mounts(root Root) ([]Mount, error) {
+	f, err := os.Open(root.Path("proc", "self", "mountinfo"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var mounts []Mount
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// 36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue
+		before, after, ok := strings.Cut(scanner.Text(), " - ")
+		fields, tail := strings.Fields(before), strings.Fields(after)
+		if !ok || len(fields) < 6 || len(tail) < 2 {
+			continue
+		}
+		mounts = append(mounts, Mount{
+			DeviceID:   fields[2],
+			Mountpoint: unescapeMountinfo(fields[4]),
+			Options:    strings.Split(fields[5], ","),
+			Type:       tail[0],
+			Source:     unescapeMountinfo(tail[1]),
+		})
+	}
+	return mounts, scanner.Err()
+}
+
+// unescapeMountinfo decodes the octal escapes (\040 for space etc.) the
+// kernel uses in mountinfo paths
+func unescapeMountinfo(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if n, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(n))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// Filesystems reports every real mounted filesystem with its usage. Bind
+// mounts of an already listed device are skipped, as is a container's
+// overlay storage other than its root.
+func Filesystems(ctx context.Context, root Root) ([]Filesystem, error) {
+	mounts, err := Mounts(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var filesystems []Filesystem
+	seen := make(map[string]bool)
+	for _, m := range mounts {
+		if pseudoFilesystems[m.Type] || (m.Type == "overlay" && m.Mountpoint != "/") || seen[m.DeviceID] {
+			continue
+		}
+		seen[m.DeviceID] = true
+
+		fs := Filesystem{Device: m.Source, Mountpoint: m.Mountpoint, Type: m.Type}
+		for _, opt := range m.Options {
+			if opt == "ro" {
+				fs.ReadOnly = true
+			}
+		}
+		err := statWithTimeout(ctx, root.Path(m.Mountpoint), &fs)
+		if err == errStatTimeout {
+			fs.Unresponsive = true
+			filesystems = append(filesystems, fs)
+			continue
+		}
+		if err != nil {
+			continue // Stale NFS handles and unreadable mounts are left out
+		}
+		if fs.Used+fs.Free > 0 {
+			// Like df, relative to the space available to unprivileged users
+			fs.UsedPercent = float64(fs.Used*1000/(fs.Used+fs.Free)) / 10
+		}
+		filesystems = append(filesystems, fs)
+	}
+	return filesystems, nil
+}
+
+var errStatTimeout = errors.New("statfs timed out")
+
+// statWithTimeout runs statFilesystem under statTimeout. A hung statfs
+// can't be interrupted, so its goroutine is abandoned; it ends with the
+// process or when the mount recovers.
+func statWithTimeout(ctx context.Context, path string, fs *Filesystem) error {
+	ctx, cancel := context.WithTimeout(ctx, statTimeout)
+	defer cancel()
+
+	var result Filesystem
+	done := make(chan error, 1)
+	go func() {
+		done <- statFilesystem(path, &result)
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			fs.Total, fs.Used, fs.Free = result.Total, result.Used, result.Free
+			fs.Inodes, fs.InodesUsed, fs.InodesFree = result.Inodes, result.InodesUsed, result.InodesFree
+		}
+		return err
+	case <-ctx.Done():
+		return errStatTimeout
+	}
+}
+
+// NewFilesystemCollector reports mounted filesystems and their usage
+func NewFilesystemCollector(root Root) Collector {
+	return NewCollector("filesystems", linuxOnly, func(ctx context.Context) (interface{}, error) {
+		return Filesystems(ctx, root)
+	})
+}
@@ -0,0 +1,55 @@
+package inventory
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// HostKey is one of the SSH server's host keys
+type HostKey struct {
+	Type        string `json:"type"`
+	Fingerprint string `json:"fingerprint"`
+	// PublicKey is "<type> <base64>" as used in known_hosts, without the
+	// comment
+	PublicKey string `json:"public_key"`
+}
+
+// sshHostKeyGlob matches the host public keys, relative to the root
+var sshHostKeyGlob = "etc/ssh/ssh_host_*_key.pub"
+
+// HostKeys reads the SSH host public keys, sorted by type
+func HostKeys(root Root) ([]HostKey, error) {
+	files, err := filepath.Glob(root.Path(sshHostKeyGlob))
+	if err != nil {
+		return nil, err
+	}
+	keys := []HostKey{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		key, blob, err := parseSSHPublicKey(strings.TrimSpace(string(data)))
+		if err != nil {
+			continue
+		}
+		keys = append(keys, HostKey{
+			Type:        key.Type,
+			Fingerprint: key.Fingerprint,
+			PublicKey:   key.Type + " " + base64.StdEncoding.EncodeToString(blob),
+		})
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Type < keys[j].Type })
+	return keys, nil
+}
+
+// NewHostKeyCollector reports the SSH host keys
+func NewHostKeyCollector(root Root) Collector {
+	return NewCollector("ssh_host_keys", linuxOnly, func(ctx context.Context) (interface{}, error) {
+		return HostKeys(root)
+	})
+}
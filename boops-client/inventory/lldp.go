@@ -0,0 +1,231 @@
+package inventory
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// LLDPNeighbor is the switch port seen on the other end of an interface
+type LLDPNeighbor struct {
+	ChassisID         string `json:"chassis_id,omitempty"`
+	SystemName        string `json:"system_name,omitempty"`
+	PortID            string `json:"port_id,omitempty"`
+	PortDescription   string `json:"port_description,omitempty"`
+	ManagementAddress string `json:"management_address,omitempty"`
+	VLANID            int    `json:"vlan_id,omitempty"`
+	VLANName          string `json:"vlan_name,omitempty"`
+}
+
+// LLDPNeighbors are the neighbors seen on each interface
+type LLDPNeighbors map[string][]LLDPNeighbor
+
+// lldpListenTime is how long the raw listener waits for frames. Switches
+// send LLDP every 30 seconds by default.
+var lldpListenTime = 35 * time.Second
+
+// LLDP reads the neighbors lldpd has learned. With listen set and lldpd not
+// answering, because lldpctl is missing or the daemon isn't running, it
+// captures LLDP frames on the physical interfaces itself.
+func LLDP(ctx context.Context, root Root, listen bool) (LLDPNeighbors, error) {
+	output, err := runCommand(ctx, "lldpctl", "-f", "json")
+	if err == nil {
+		return parseLldpctl(output)
+	}
+	if !listen {
+		return nil, err
+	}
+
+	nics, err := NICs(root)
+	if err != nil {
+		return nil, err
+	}
+	var ifaces []string
+	for _, nic := range nics {
+		if nic.Kind == KindPhysical && nic.OperState == "up" {
+			ifaces = append(ifaces, nic.Name)
+		}
+	}
+	return listenLLDP(ctx, ifaces, lldpListenTime)
+}
+
+// parseLldpctl decodes "lldpctl -f json". lldpd collapses single-element
+// lists into objects and keys chassis by system name, so every level is
+// decoded loosely.
+func parseLldpctl(output []byte) (LLDPNeighbors, error) {
+	var doc struct {
+		LLDP struct {
+			Interface interface{} `json:"interface"`
+		} `json:"lldp"`
+	}
+	if err := json.Unmarshal(output, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse lldpctl output: %v", err)
+	}
+
+	neighbors := make(LLDPNeighbors)
+	for _, entry := range jsonList(doc.LLDP.Interface) {
+		ifaces, _ := entry.(map[string]interface{})
+		for iface, data := range ifaces {
+			fields, _ := data.(map[string]interface{})
+			var n LLDPNeighbor
+
+			for name, chassis := range jsonObject(fields["chassis"]) {
+				c := jsonObject(chassis)
+				if _, keyed := c["id"]; !keyed {
+					// Unnamed chassis aren't keyed by name
+					c, name = jsonObject(fields["chassis"]), ""
+				}
+				n.SystemName = name
+				n.ChassisID = jsonString(jsonObject(c["id"])["value"])
+				if ips := jsonList(c["mgmt-ip"]); len(ips) > 0 {
+					n.ManagementAddress = jsonString(ips[0])
+				}
+				break
+			}
+			port := jsonObject(fields["port"])
+			n.PortID = jsonString(jsonObject(port["id"])["value"])
+			n.PortDescription = jsonString(port["descr"])
+			for _, v := range jsonList(fields["vlan"]) {
+				vlan := jsonObject(v)
+				n.VLANID, _ = strconv.Atoi(jsonString(vlan["vlan-id"]))
+				n.VLANName = jsonString(vlan["value"])
+				if pvid, _ := vlan["pvid"].(bool); pvid {
+					break
+				}
+			}
+			neighbors[iface] = append(neighbors[iface], n)
+		}
+	}
+	return neighbors, nil
+}
+
+func jsonList(v interface{}) []interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		return v
+	case nil:
+		return nil
+	default:
+		return []interface{}{v}
+	}
+}
+
+func jsonObject(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	return m
+}
+
+func jsonString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+// LLDP TLV types (IEEE 802.1AB)
+const (
+	lldpTLVEnd         = 0
+	lldpTLVChassisID   = 1
+	lldpTLVPortID      = 2
+	lldpTLVPortDescr   = 4
+	lldpTLVSystemName  = 5
+	lldpTLVMgmtAddress = 8
+	lldpTLVOrgSpecific = 127
+)
+
+// lldpOUI8021 is the IEEE 802.1 organizationally specific TLV OUI
+var lldpOUI8021 = []byte{0x00, 0x80, 0xc2}
+
+// parseLLDPDU decodes the TLVs of an LLDP frame payload (after the
+// Ethernet header)
+func parseLLDPDU(b []byte) (LLDPNeighbor, error) {
+	var n LLDPNeighbor
+	for len(b) >= 2 {
+		header := binary.BigEndian.Uint16(b)
+		typ, length := int(header>>9), int(header&0x1ff)
+		if len(b) < 2+length {
+			return n, fmt.Errorf("truncated LLDP TLV type %d", typ)
+		}
+		value := b[2 : 2+length]
+		b = b[2+length:]
+
+		switch typ {
+		case lldpTLVEnd:
+			return n, nil
+		case lldpTLVChassisID:
+			n.ChassisID = lldpID(value)
+		case lldpTLVPortID:
+			n.PortID = lldpID(value)
+		case lldpTLVPortDescr:
+			n.PortDescription = string(value)
+		case lldpTLVSystemName:
+			n.SystemName = string(value)
+		case lldpTLVMgmtAddress:
+			// address string length (subtype included), address subtype
+			// (1 IPv4, 2 IPv6), address
+			if len(value) < 2 || value[0] < 1 || 1+int(value[0]) > len(value) || n.ManagementAddress != "" {
+				continue
+			}
+			if addr := value[2 : 1+int(value[0])]; len(addr) == net.IPv4len || len(addr) == net.IPv6len {
+				n.ManagementAddress = net.IP(addr).String()
+			}
+		case lldpTLVOrgSpecific:
+			if len(value) < 4 || string(value[:3]) != string(lldpOUI8021) {
+				continue
+			}
+			switch value[3] {
+			case 1: // Port VLAN ID
+				if len(value) >= 6 {
+					n.VLANID = int(binary.BigEndian.Uint16(value[4:]))
+				}
+			case 3: // VLAN name: VID, name length, name
+				if len(value) >= 7 && n.VLANName == "" && len(value) >= 7+int(value[6]) {
+					n.VLANName = string(value[7 : 7+int(value[6])])
+				}
+			}
+		}
+	}
+	return n, nil
+}
+
+// lldpID formats a chassis or port ID TLV: MAC addresses (subtype 3 for
+// ports, 4 for chassis) as colon-separated hex, everything else as text
+func lldpID(value []byte) string {
+	if len(value) < 2 {
+		return ""
+	}
+	subtype, id := value[0], value[1:]
+	if (subtype == 3 || subtype == 4) && len(id) == 6 {
+		return net.HardwareAddr(id).String()
+	}
+	return string(id)
+}
+
+// NewLLDPCollector reports LLDP neighbors per interface. listen enables the
+// raw frame listener, which keeps the collector busy for lldpListenTime.
+func NewLLDPCollector(root Root, listen bool) Collector {
+	c := NewCollector("lldp", linuxOnly, func(ctx context.Context) (interface{}, error) {
+		neighbors, err := LLDP(ctx, root, listen)
+		if err != nil {
+			return nil, err
+		}
+		for iface := range neighbors {
+			sort.Slice(neighbors[iface], func(i, j int) bool {
+				return neighbors[iface][i].ChassisID < neighbors[iface][j].ChassisID
+			})
+		}
+		return neighbors, nil
+	})
+	if listen {
+		return WithTimeout(c, lldpListenTime+5*time.Second)
+	}
+	return c
+}
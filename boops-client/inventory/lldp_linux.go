@@ -0,0 +1,131 @@
+package inventory
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const (
+	ethPLLDP            = 0x88cc
+	solPacket           = 263
+	packetAddMembership = 1
+	packetMRMulticast   = 0
+)
+
+// lldpMulticast is the nearest-bridge group address LLDP frames are sent to
+var lldpMulticast = net.HardwareAddr{0x01, 0x80, 0xc2, 0x00, 0x00, 0x0e}
+
+// packetMreq is struct packet_mreq
+type packetMreq struct {
+	ifindex int32
+	typ     uint16
+	alen    uint16
+	address [8]byte
+}
+
+// listenLLDP captures the first LLDP frame on each interface, waiting at
+// most d
+func listenLLDP(ctx context.Context, ifaces []string, d time.Duration) (LLDPNeighbors, error) {
+	ctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+
+	neighbors := make(LLDPNeighbors)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	errs := make([]error, len(ifaces))
+	for i, iface := range ifaces {
+		wg.Add(1)
+		go func(i int, iface string) {
+			defer wg.Done()
+			// The registry only recovers the collector's own goroutine, and
+			// frames come from anyone on the link
+			defer func() {
+				if p := recover(); p != nil {
+					errs[i] = fmt.Errorf("LLDP listener on %s panicked: %v", iface, p)
+				}
+			}()
+			n, ok, err := captureLLDP(ctx, iface)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			if ok {
+				mu.Lock()
+				neighbors[iface] = append(neighbors[iface], n)
+				mu.Unlock()
+			}
+		}(i, iface)
+	}
+	wg.Wait()
+
+	// Interfaces that simply heard nothing are not an error
+	for _, err := range errs {
+		if err != nil && len(neighbors) == 0 {
+			return nil, err
+		}
+	}
+	return neighbors, nil
+}
+
+func captureLLDP(ctx context.Context, iface string) (LLDPNeighbor, bool, error) {
+	ifi, err := net.InterfaceByName(iface)
+	if err != nil {
+		return LLDPNeighbor{}, false, err
+	}
+	proto := htons(ethPLLDP)
+	fd, err := syscall.Socket(syscall.AF_PACKET, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, int(proto))
+	if err != nil {
+		return LLDPNeighbor{}, false, err
+	}
+	defer syscall.Close(fd)
+
+	if err := syscall.Bind(fd, &syscall.SockaddrLinklayer{Protocol: proto, Ifindex: ifi.Index}); err != nil {
+		return LLDPNeighbor{}, false, err
+	}
+	// Make sure the NIC doesn't filter out the link-local multicast group
+	mreq := packetMreq{ifindex: int32(ifi.Index), typ: packetMRMulticast, alen: uint16(len(lldpMulticast))}
+	copy(mreq.address[:], lldpMulticast)
+	raw := (*[unsafe.Sizeof(mreq)]byte)(unsafe.Pointer(&mreq))[:]
+	if err := syscall.SetsockoptString(fd, solPacket, packetAddMembership, string(raw)); err != nil {
+		return LLDPNeighbor{}, false, err
+	}
+	// Wake up every second to notice the deadline
+	tv := syscall.Timeval{Sec: 1}
+	if err := syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &tv); err != nil {
+		return LLDPNeighbor{}, false, err
+	}
+
+	buf := make([]byte, 9216)
+	for ctx.Err() == nil {
+		n, _, err := syscall.Recvfrom(fd, buf, 0)
+		if err == syscall.EAGAIN || err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			return LLDPNeighbor{}, false, err
+		}
+		// Destination and source MAC, then the EtherType
+		if n < 14 || buf[12] != 0x88 || buf[13] != 0xcc {
+			continue
+		}
+		neighbor, err := parseLLDPDU(buf[14:n])
+		if err != nil {
+			continue
+		}
+		return neighbor, true, nil
+	}
+	return LLDPNeighbor{}, false, nil
+}
+
+// htons converts v to network byte order, whatever the host's byte order is
+func htons(v uint16) uint16 {
+	var b [2]byte
+	binary.BigEndian.PutUint16(b[:], v)
+	return binary.NativeEndian.Uint16(b[:])
+}
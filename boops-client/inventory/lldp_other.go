@@ -0,0 +1,14 @@
+//go:build !linux
+
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// listenLLDP needs AF_PACKET sockets, which only exist on Linux
+func listenLLDP(ctx context.Context, ifaces []string, d time.Duration) (LLDPNeighbors, error) {
+	return nil, fmt.Errorf("LLDP listener: %w", ErrNotAvailable)
+}
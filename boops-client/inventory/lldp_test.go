@@ -0,0 +1,106 @@
+package inventory
+
+import "testing"
+
+// lldpTLV encodes one TLV with its 7-bit type and 9-bit length header
+func lldpTLV(typ int, value ...byte) []byte {
+	header := typ<<9 | len(value)
+	return append([]byte{byte(header >> 8), byte(header)}, value...)
+}
+
+func lldpDU(tlvs ...[]byte) []byte {
+	var b []byte
+	for _, tlv := range tlvs {
+		b = append(b, tlv...)
+	}
+	return b
+}
+
+func TestParseLLDPDU(t *testing.T) {
+	tests := []struct {
+		name    string
+		frame   []byte
+		want    LLDPNeighbor
+		wantErr bool
+	}{
+		{
+			name: "full",
+			frame: lldpDU(
+				lldpTLV(lldpTLVChassisID, 4, 0x00, 0x11, 0x22, 0x33, 0x44, 0x55),
+				lldpTLV(lldpTLVPortID, 5, 'g', 'e', '0'),
+				lldpTLV(3, 0, 120), // TTL
+				lldpTLV(lldpTLVPortDescr, 'u', 'p'),
+				lldpTLV(lldpTLVSystemName, 's', 'w', '1'),
+				lldpTLV(lldpTLVMgmtAddress, 5, 1, 10, 0, 0, 1, 2, 0, 0, 0, 1, 0),
+				lldpTLV(lldpTLVOrgSpecific, 0x00, 0x80, 0xc2, 1, 0, 20),
+				lldpTLV(lldpTLVOrgSpecific, 0x00, 0x80, 0xc2, 3, 0, 20, 3, 'a', 'b', 'c'),
+				lldpTLV(lldpTLVEnd),
+			),
+			want: LLDPNeighbor{
+				ChassisID:         "00:11:22:33:44:55",
+				SystemName:        "sw1",
+				PortID:            "ge0",
+				PortDescription:   "up",
+				ManagementAddress: "10.0.0.1",
+				VLANID:            20,
+				VLANName:          "abc",
+			},
+		},
+		{
+			name:  "IPv6 management address",
+			frame: lldpDU(lldpTLV(lldpTLVMgmtAddress, 17, 2, 0xfe, 0x80, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1)),
+			want:  LLDPNeighbor{ManagementAddress: "fe80::1"},
+		},
+		{
+			name:  "zero management address length",
+			frame: lldpDU(lldpTLV(lldpTLVMgmtAddress, 0, 1), lldpTLV(lldpTLVSystemName, 's')),
+			want:  LLDPNeighbor{SystemName: "s"},
+		},
+		{
+			name:  "management address length past the TLV",
+			frame: lldpDU(lldpTLV(lldpTLVMgmtAddress, 200, 1, 10, 0, 0, 1)),
+		},
+		{
+			name:  "odd management address length",
+			frame: lldpDU(lldpTLV(lldpTLVMgmtAddress, 3, 1, 10, 0)),
+		},
+		{
+			name:  "short chassis ID",
+			frame: lldpDU(lldpTLV(lldpTLVChassisID, 4)),
+		},
+		{
+			name:  "short org specific TLVs",
+			frame: lldpDU(lldpTLV(lldpTLVOrgSpecific, 0x00, 0x80), lldpTLV(lldpTLVOrgSpecific, 0x00, 0x80, 0xc2, 1, 0)),
+		},
+		{
+			name:  "VLAN name longer than the TLV",
+			frame: lldpDU(lldpTLV(lldpTLVOrgSpecific, 0x00, 0x80, 0xc2, 3, 0, 20, 9, 'a')),
+		},
+		{
+			name:    "TLV length past the frame",
+			frame:   append(lldpTLV(lldpTLVSystemName, 's', 'w'), 0x0a, 0x10, 's'),
+			want:    LLDPNeighbor{SystemName: "sw"},
+			wantErr: true,
+		},
+		{
+			name:  "trailing odd byte",
+			frame: append(lldpTLV(lldpTLVSystemName, 's'), 0x0a),
+			want:  LLDPNeighbor{SystemName: "s"},
+		},
+		{
+			name: "empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseLLDPDU(tt.frame)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseLLDPDU() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseLLDPDU() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// lvmReport is the shape of "lvm <cmd> --reportformat json" output
+type lvmReport struct {
+	Report []struct {
+		LV []map[string]string `json:"lv"`
+		PV []map[string]string `json:"pv"`
+	} `json:"report"`
+}
+
+// lvmRows runs an lvm reporting command and returns the rows of section
+func lvmRows(ctx context.Context, section string, args ...string) ([]map[string]string, error) {
+	args = append(args, "--reportformat", "json", "--units", "b", "--nosuffix")
+	output, err := runCommand(ctx, "lvm", args...)
+	if err != nil {
+		return nil, err
+	}
+	return parseLVMReport(output, section)
+}
+
+// parseLVMReport returns the rows of section ("lv" or "pv") of a JSON report
+func parseLVMReport(output []byte, section string) ([]map[string]string, error) {
+	var report lvmReport
+	if err := json.Unmarshal(output, &report); err != nil {
+		return nil, err
+	}
+	var rows []map[string]string
+	for _, r := range report.Report {
+		if section == "lv" {
+			rows = append(rows, r.LV...)
+		} else {
+			rows = append(rows, r.PV...)
+		}
+	}
+	return rows, nil
+}
+
+// LogicalVolumes returns every visible logical volume with the physical
+// volumes (or hidden RAID/mirror sub-volumes) it is placed on as children
+func LogicalVolumes(ctx context.Context) ([]StorageNode, error) {
+	lvRows, err := lvmRows(ctx, "lv", "lvs", "-a", "-o", "lv_name,vg_name,lv_size,segtype,devices,lv_health_status")
+	if err != nil {
+		return nil, err
+	}
+	pvRows, err := lvmRows(ctx, "pv", "pvs", "-o", "pv_name,vg_name,pv_size,pv_missing")
+	if err != nil {
+		return nil, err
+	}
+	return logicalVolumeTree(lvRows, pvRows), nil
+}
+
+// logicalVolumeTree builds the LV trees from "lvs -a" and "pvs" rows
+func logicalVolumeTree(lvRows, pvRows []map[string]string) []StorageNode {
+	pvs := make(map[string]StorageNode)
+	for _, row := range pvRows {
+		size, _ := strconv.ParseUint(row["pv_size"], 10, 64)
+		pv := StorageNode{Name: row["pv_name"], Kind: StoragePV, Group: row["vg_name"], Size: size}
+		if row["pv_missing"] != "" {
+			pv.State, pv.Degraded = "missing", true
+		}
+		pvs[pv.Name] = pv
+	}
+
+	// lvs -a reports one row per segment, and hidden sub-volumes in brackets
+	type lvKey struct{ vg, name string }
+	lvs := make(map[lvKey]StorageNode)
+	devices := make(map[lvKey][]string)
+	var order []lvKey
+	for _, row := range lvRows {
+		key := lvKey{row["vg_name"], strings.Trim(row["lv_name"], "[]")}
+		lv, seen := lvs[key]
+		if !seen {
+			size, _ := strconv.ParseUint(row["lv_size"], 10, 64)
+			lv = StorageNode{Name: key.vg + "/" + key.name, Kind: StorageLV, Group: key.vg, Level: row["segtype"], Size: size}
+			if health := row["lv_health_status"]; health != "" {
+				lv.State, lv.Degraded = health, true
+			}
+			if !strings.HasPrefix(row["lv_name"], "[") {
+				order = append(order, key)
+			}
+		}
+		lvs[key] = lv
+		for _, dev := range strings.Split(row["devices"], ",") {
+			// "/dev/md0(0)" or "[root_rimage_0](0)"
+			if dev, _, _ = strings.Cut(dev, "("); dev != "" {
+				devices[key] = append(devices[key], dev)
+			}
+		}
+	}
+
+	var build func(key lvKey, depth int) StorageNode
+	build = func(key lvKey, depth int) StorageNode {
+		lv := lvs[key]
+		seen := make(map[string]bool)
+		for _, dev := range devices[key] {
+			if seen[dev] {
+				continue
+			}
+			seen[dev] = true
+			sub := lvKey{key.vg, strings.Trim(dev, "[]")}
+			if _, ok := lvs[sub]; ok && depth < 8 {
+				lv.Children = append(lv.Children, build(sub, depth+1))
+			} else if pv, ok := pvs[dev]; ok {
+				lv.Children = append(lv.Children, pv)
+			} else {
+				lv.Children = append(lv.Children, StorageNode{Name: dev, Kind: StoragePV, Group: key.vg})
+			}
+		}
+		return lv
+	}
+
+	var result []StorageNode
+	for _, key := range order {
+		result = append(result, build(key, 0))
+	}
+	return result
+}
@@ -0,0 +1,76 @@
+package inventory
+
+import (
+	"reflect"
+	"testing"
+)
+
+const lvsFixture = `{
+  "report": [{
+    "lv": [
+      {"lv_name":"root", "vg_name":"vg0", "lv_size":"10737418240", "segtype":"raid1", "devices":"[root_rimage_0](0),[root_rimage_1](0)", "lv_health_status":"partial"},
+      {"lv_name":"[root_rimage_0]", "vg_name":"vg0", "lv_size":"10737418240", "segtype":"linear", "devices":"/dev/sda2(1)", "lv_health_status":""},
+      {"lv_name":"[root_rimage_1]", "vg_name":"vg0", "lv_size":"10737418240", "segtype":"linear", "devices":"/dev/sdb2(1)", "lv_health_status":"partial"},
+      {"lv_name":"[root_rmeta_0]", "vg_name":"vg0", "lv_size":"4194304", "segtype":"linear", "devices":"/dev/sda2(0)", "lv_health_status":""},
+      {"lv_name":"[root_rmeta_1]", "vg_name":"vg0", "lv_size":"4194304", "segtype":"linear", "devices":"/dev/sdb2(0)", "lv_health_status":"partial"},
+      {"lv_name":"data", "vg_name":"vg1", "lv_size":"21474836480", "segtype":"linear", "devices":"/dev/md0(0)", "lv_health_status":""},
+      {"lv_name":"data", "vg_name":"vg1", "lv_size":"21474836480", "segtype":"linear", "devices":"/dev/sdc1(0)", "lv_health_status":""},
+      {"lv_name":"data", "vg_name":"vg1", "lv_size":"21474836480", "segtype":"linear", "devices":"/dev/md0(2560)", "lv_health_status":""},
+      {"lv_name":"thin", "vg_name":"vg1", "lv_size":"1073741824", "segtype":"linear", "devices":"/dev/sdz1(0)", "lv_health_status":""}
+    ]
+  }]
+}`
+
+const pvsFixture = `{
+  "report": [{
+    "pv": [
+      {"pv_name":"/dev/sda2", "vg_name":"vg0", "pv_size":"20000000000", "pv_missing":""},
+      {"pv_name":"/dev/sdb2", "vg_name":"vg0", "pv_size":"20000000000", "pv_missing":"missing"},
+      {"pv_name":"/dev/md0", "vg_name":"vg1", "pv_size":"30000000000", "pv_missing":""},
+      {"pv_name":"/dev/sdc1", "vg_name":"vg1", "pv_size":"40000000000", "pv_missing":""}
+    ]
+  }]
+}`
+
+func TestLogicalVolumeTree(t *testing.T) {
+	lvRows, err := parseLVMReport([]byte(lvsFixture), "lv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pvRows, err := parseLVMReport([]byte(pvsFixture), "pv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lvRows) != 9 || len(pvRows) != 4 {
+		t.Fatalf("parseLVMReport() = %d lv and %d pv rows, want 9 and 4", len(lvRows), len(pvRows))
+	}
+
+	sda2 := StorageNode{Name: "/dev/sda2", Kind: StoragePV, Group: "vg0", Size: 20000000000}
+	sdb2 := StorageNode{Name: "/dev/sdb2", Kind: StoragePV, Group: "vg0", Size: 20000000000, State: "missing", Degraded: true}
+	want := []StorageNode{
+		{Name: "vg0/root", Kind: StorageLV, Group: "vg0", Level: "raid1", Size: 10737418240, State: "partial", Degraded: true, Children: []StorageNode{
+			{Name: "vg0/root_rimage_0", Kind: StorageLV, Group: "vg0", Level: "linear", Size: 10737418240, Children: []StorageNode{sda2}},
+			{Name: "vg0/root_rimage_1", Kind: StorageLV, Group: "vg0", Level: "linear", Size: 10737418240, State: "partial", Degraded: true, Children: []StorageNode{sdb2}},
+		}},
+		// One child per device, however many segments are on it
+		{Name: "vg1/data", Kind: StorageLV, Group: "vg1", Level: "linear", Size: 21474836480, Children: []StorageNode{
+			{Name: "/dev/md0", Kind: StoragePV, Group: "vg1", Size: 30000000000},
+			{Name: "/dev/sdc1", Kind: StoragePV, Group: "vg1", Size: 40000000000},
+		}},
+		// A device pvs does not list still shows up
+		{Name: "vg1/thin", Kind: StorageLV, Group: "vg1", Level: "linear", Size: 1073741824, Children: []StorageNode{
+			{Name: "/dev/sdz1", Kind: StoragePV, Group: "vg1"},
+		}},
+	}
+
+	got := logicalVolumeTree(lvRows, pvRows)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("logicalVolumeTree() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseLVMReportInvalid(t *testing.T) {
+	if _, err := parseLVMReport([]byte("  WARNING: not json"), "lv"); err == nil {
+		t.Error("parseLVMReport() error = nil, want error")
+	}
+}
@@ -0,0 +1,117 @@
+package inventory
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// mdMemberPattern matches an array member in /proc/mdstat, e.g. "sdb1[1](F)"
+var mdMemberPattern = regexp.MustCompile(`^([^\[\s]+)\[(\d+)\]((?:\([A-Z]\))*)$`)
+
+// mdProgressPattern matches a resync/recovery progress line in /proc/mdstat
+var mdProgressPattern = regexp.MustCompile(`\b(resync|recovery|check|reshape|repair)\s*=\s*([\d.]+%)`)
+
+// mdMemberFlags maps /proc/mdstat member flags to sysfs member states
+var mdMemberFlags = map[string]string{
+	"(F)": "faulty",
+	"(S)": "spare",
+	"(W)": "write_mostly",
+	"(R)": "replacement",
+	"(J)": "journal",
+}
+
+// MDArrays lists the md RAID arrays in /proc/mdstat. The sysfs md directory
+// supplies the level, degraded count and sync action when present.
+func MDArrays(root Root) ([]StorageNode, error) {
+	f, err := os.Open(root.Path("proc", "mdstat"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var arrays []StorageNode
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// md0 : active raid1 sdb1[1] sda1[0]
+		line := scanner.Text()
+		if m := mdProgressPattern.FindStringSubmatch(line); m != nil && len(arrays) > 0 {
+			arrays[len(arrays)-1].State += ", " + m[1] + " " + m[2]
+			continue
+		}
+		name, rest, ok := strings.Cut(line, " : ")
+		if !ok || !strings.HasPrefix(name, "md") {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+		md := StorageNode{Name: strings.TrimSpace(name), Kind: StorageMD, State: fields[0]}
+		for _, field := range fields[1:] {
+			if m := mdMemberPattern.FindStringSubmatch(field); m != nil {
+				member := StorageNode{Name: m[1], Kind: StorageDisk, State: "in_sync"}
+				if state, ok := mdMemberFlags[m[3]]; ok {
+					member.State = state
+					member.Degraded = state == "faulty"
+				}
+				md.Children = append(md.Children, member)
+			} else if !strings.HasPrefix(field, "(") && md.Level == "" {
+				md.Level = field // Skips "(auto-read-only)"
+			}
+		}
+
+		// The status line holds [raid disks/working disks] and [UU_]
+		if scanner.Scan() {
+			for _, field := range strings.Fields(scanner.Text()) {
+				if strings.HasPrefix(field, "[") && strings.Contains(field, "_") {
+					md.Degraded = true
+				}
+			}
+		}
+		arrays = append(arrays, md)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	for i := range arrays {
+		mdSysfs(root, &arrays[i])
+	}
+	return arrays, nil
+}
+
+// mdSysfs refines an array from /sys/block/<md>/md
+func mdSysfs(root Root, md *StorageNode) {
+	dir := []string{"sys", "block", md.Name}
+	attr := func(elem ...string) string {
+		v, _ := root.ReadString(append(append([]string{}, dir...), elem...)...)
+		return v
+	}
+
+	if sectors, err := root.readUint(append(dir, "size")...); err == nil {
+		md.Size = sectors * 512
+	}
+	if level := attr("md", "level"); level != "" {
+		md.Level = level
+	}
+	if state := attr("md", "array_state"); state != "" {
+		if _, progress, ok := strings.Cut(md.State, ", "); ok {
+			state += ", " + progress
+		}
+		md.State = state
+	}
+	if n, err := strconv.Atoi(attr("md", "degraded")); err == nil && n > 0 {
+		md.Degraded = true
+	}
+	if action := attr("md", "sync_action"); action != "" && action != "idle" && !strings.Contains(md.State, action) {
+		md.State += ", " + action // resync, recover, check, reshape
+	}
+	for i := range md.Children {
+		if state := attr("md", "dev-"+md.Children[i].Name, "state"); state != "" {
+			md.Children[i].State = state
+			md.Children[i].Degraded = strings.Contains(state, "faulty")
+		}
+	}
+}
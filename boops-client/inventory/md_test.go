@@ -0,0 +1,68 @@
+package inventory
+
+import (
+	"reflect"
+	"testing"
+)
+
+const mdstatFixture = `Personalities : [raid1] [raid6] [raid5] [raid4]
+md1 : active raid5 sdd1[3] sdc1[2](F) sdb1[1] sda1[0]
+      2929890816 blocks super 1.2 level 5, 512k chunk, algorithm 2 [4/3] [UU_U]
+      [==>..................]  recovery = 12.6% (123456/976630272) finish=100.0min speed=100000K/sec
+      bitmap: 0/8 pages [0KB], 65536KB chunk
+
+md0 : active raid1 sdb2[1] sda2[0]
+      523264 blocks super 1.2 [2/2] [UU]
+
+md127 : active (auto-read-only) raid1 sde1[0] sdf1[1](S)
+      1000 blocks [2/1] [U_]
+
+unused devices: <none>
+`
+
+func TestMDArrays(t *testing.T) {
+	root := fixtureRoot(t, map[string]string{
+		"proc/mdstat":                       mdstatFixture,
+		"sys/block/md0/size":                "1046528\n",
+		"sys/block/md0/md/level":            "raid1\n",
+		"sys/block/md0/md/array_state":      "clean\n",
+		"sys/block/md0/md/degraded":         "0\n",
+		"sys/block/md0/md/sync_action":      "check\n",
+		"sys/block/md0/md/dev-sdb2/state":   "in_sync,write_mostly\n",
+		"sys/block/md1/md/array_state":      "active\n",
+		"sys/block/md1/md/sync_action":      "recover\n",
+		"sys/block/md127/md/degraded":       "1\n",
+		"sys/block/md127/md/dev-sdf1/state": "spare\n",
+		"sys/block/md127/md/dev-sde1/state": "in_sync\n",
+	})
+	want := []StorageNode{
+		{Name: "md1", Kind: StorageMD, Level: "raid5", State: "active, recovery 12.6%", Degraded: true, Children: []StorageNode{
+			{Name: "sdd1", Kind: StorageDisk, State: "in_sync"},
+			{Name: "sdc1", Kind: StorageDisk, State: "faulty", Degraded: true},
+			{Name: "sdb1", Kind: StorageDisk, State: "in_sync"},
+			{Name: "sda1", Kind: StorageDisk, State: "in_sync"},
+		}},
+		{Name: "md0", Kind: StorageMD, Level: "raid1", Size: 1046528 * 512, State: "clean, check", Children: []StorageNode{
+			{Name: "sdb2", Kind: StorageDisk, State: "in_sync,write_mostly"},
+			{Name: "sda2", Kind: StorageDisk, State: "in_sync"},
+		}},
+		{Name: "md127", Kind: StorageMD, Level: "raid1", State: "active", Degraded: true, Children: []StorageNode{
+			{Name: "sde1", Kind: StorageDisk, State: "in_sync"},
+			{Name: "sdf1", Kind: StorageDisk, State: "spare"},
+		}},
+	}
+
+	got, err := MDArrays(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MDArrays() = %+v, want %+v", got, want)
+	}
+}
+
+func TestMDArraysMissing(t *testing.T) {
+	if _, err := MDArrays(fixtureRoot(t, nil)); !isNotAvailable(err) {
+		t.Errorf("MDArrays() error = %v, want not available", err)
+	}
+}
@@ -0,0 +1,66 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MemTotal returns the usable physical memory in bytes from /proc/meminfo
+func MemTotal(root Root) (uint64, error) {
+	info, err := root.readKeyValues(":", "proc", "meminfo")
+	if err != nil {
+		return 0, err
+	}
+	return parseMeminfoBytes(info["MemTotal"])
+}
+
+// parseMeminfoBytes converts a /proc/meminfo value such as "16310672 kB"
+func parseMeminfoBytes(v string) (uint64, error) {
+	fields := strings.Fields(v)
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("missing meminfo value")
+	}
+	n, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid meminfo value %q: %v", v, err)
+	}
+	if len(fields) > 1 && fields[1] == "kB" {
+		n *= 1024
+	}
+	return n, nil
+}
+
+// Memory is the memory inventory of the host
+type Memory struct {
+	// Total is the memory usable by the OS, in bytes
+	Total uint64 `json:"total_bytes"`
+	// Installed is the sum of all populated modules, in bytes
+	Installed uint64 `json:"installed_bytes,omitempty"`
+	Modules   []DIMM `json:"modules,omitempty"`
+}
+
+// ReadMemory returns the usable memory and, when the SMBIOS tables are
+// readable, the installed memory modules
+func ReadMemory(root Root) (*Memory, error) {
+	total, err := MemTotal(root)
+	if err != nil {
+		return nil, err
+	}
+	mem := &Memory{Total: total}
+	if structures, err := ReadSMBIOS(root); err == nil {
+		mem.Modules = DIMMs(structures)
+		for _, dimm := range mem.Modules {
+			mem.Installed += dimm.Size
+		}
+	}
+	return mem, nil
+}
+
+// NewMemoryCollector reports the total memory in bytes and the DIMMs
+func NewMemoryCollector(root Root) Collector {
+	return NewCollector("memory", linuxOnly, func(ctx context.Context) (interface{}, error) {
+		return ReadMemory(root)
+	})
+}
@@ -0,0 +1,63 @@
+package inventory
+
+import "testing"
+
+func TestParseMeminfoBytes(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    uint64
+		wantErr bool
+	}{
+		{value: "16310672 kB", want: 16310672 * 1024},
+		{value: "  2048 kB", want: 2048 * 1024},
+		{value: "0 kB", want: 0},
+		{value: "42", want: 42},
+		{value: "", wantErr: true},
+		{value: "lots kB", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseMeminfoBytes(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseMeminfoBytes(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseMeminfoBytes(%q) = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestReadMemory(t *testing.T) {
+	tests := []struct {
+		name    string
+		meminfo string
+		want    uint64
+		wantErr bool
+	}{
+		{
+			name:    "meminfo",
+			meminfo: "MemTotal:       16310672 kB\nMemFree:         1203344 kB\nMemAvailable:    9021932 kB\n",
+			want:    16310672 * 1024,
+		},
+		{
+			name:    "no MemTotal",
+			meminfo: "MemFree:         1203344 kB\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := fixtureRoot(t, map[string]string{"proc/meminfo": tt.meminfo})
+			mem, err := ReadMemory(root)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadMemory() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if mem.Total != tt.want || len(mem.Modules) != 0 {
+				t.Errorf("ReadMemory() = %+v, want Total %d and no modules", mem, tt.want)
+			}
+		})
+	}
+}
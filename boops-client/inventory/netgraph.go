@@ -0,0 +1,156 @@
+package inventory
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Edge types, from the most to the least specific
+const (
+	EdgeMember = "member" // To is enslaved to From (bond, bridge, team)
+	EdgeVLAN   = "vlan"   // From is a VLAN on To
+	EdgeLower  = "lower"  // From is stacked on To (macvlan, ipvlan, ...)
+	EdgePeer   = "peer"   // From and To are the two ends of a veth pair
+)
+
+var edgePriority = map[string]int{EdgeMember: 3, EdgeVLAN: 2, EdgeLower: 1, EdgePeer: 0}
+
+// NetDevice is a node of the network device graph
+type NetDevice struct {
+	Name   string `json:"name"`
+	Kind   string `json:"kind"`
+	VLANID int    `json:"vlan_id,omitempty"`
+}
+
+// NetEdge links an upper device to the device it is built on
+type NetEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Type string `json:"type"`
+}
+
+// NetGraph is the layering of the host's network devices, e.g. vmbr0 ->
+// vlan100 -> bond0 -> eno1/eno2
+type NetGraph struct {
+	Devices []NetDevice `json:"devices"`
+	Edges   []NetEdge   `json:"edges"`
+}
+
+// NetworkGraph builds the device graph from /sys/class/net master and
+// lower_*/upper_* links and /proc/net/vlan
+func NetworkGraph(root Root) (*NetGraph, error) {
+	entries, err := os.ReadDir(root.Path("sys", "class", "net"))
+	if err != nil {
+		return nil, err
+	}
+	vlans, _ := vlanConfig(root)
+
+	graph := &NetGraph{Edges: []NetEdge{}}
+	edges := make(map[[2]string]string)
+	addEdge := func(from, to, typ string) {
+		key := [2]string{from, to}
+		if current, ok := edges[key]; !ok || edgePriority[typ] > edgePriority[current] {
+			edges[key] = typ
+		}
+	}
+
+	byIndex := make(map[string]string)
+	peers := make(map[string]string)
+	for _, entry := range entries {
+		name := entry.Name()
+		dir := []string{"sys", "class", "net", name}
+		device := NetDevice{Name: name, Kind: ReadNIC(root, name).Kind}
+
+		if master, err := os.Readlink(root.Path(append(dir, "master")...)); err == nil {
+			addEdge(filepath.Base(master), name, EdgeMember)
+		}
+		attrs, _ := os.ReadDir(root.Path(dir...))
+		for _, attr := range attrs {
+			if lower, ok := strings.CutPrefix(attr.Name(), "lower_"); ok {
+				addEdge(name, lower, EdgeLower)
+			} else if upper, ok := strings.CutPrefix(attr.Name(), "upper_"); ok {
+				addEdge(upper, name, EdgeLower)
+			}
+		}
+		if vlan, ok := vlans[name]; ok {
+			device.VLANID = vlan.id
+			addEdge(name, vlan.parent, EdgeVLAN)
+		}
+
+		graph.Devices = append(graph.Devices, device)
+		if index, err := root.ReadString(append(dir, "ifindex")...); err == nil {
+			byIndex[index] = name
+		}
+		if device.Kind == KindVeth {
+			if link, err := root.ReadString(append(dir, "iflink")...); err == nil {
+				peers[name] = link
+			}
+		}
+	}
+
+	// A veth's iflink is its peer's ifindex; peers in other network
+	// namespaces don't show up here
+	for name, link := range peers {
+		if peer, ok := byIndex[link]; ok && peer != name && name < peer {
+			addEdge(name, peer, EdgePeer)
+		}
+	}
+
+	for key, typ := range edges {
+		graph.Edges = append(graph.Edges, NetEdge{From: key[0], To: key[1], Type: typ})
+	}
+	sort.Slice(graph.Devices, func(i, j int) bool { return graph.Devices[i].Name < graph.Devices[j].Name })
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		a, b := graph.Edges[i], graph.Edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.To < b.To
+	})
+	return graph, nil
+}
+
+type vlanInfo struct {
+	id     int
+	parent string
+}
+
+// vlanConfig parses /proc/net/vlan/config:
+//
+//	VLAN Dev name	 | VLAN ID
+//	Name-Type: VLAN_NAME_TYPE_RAW_PLUS_VID_NO_PAD
+//	vlan100        | 100  | bond0
+func vlanConfig(root Root) (map[string]vlanInfo, error) {
+	f, err := os.Open(root.Path("proc", "net", "vlan", "config"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	vlans := make(map[string]vlanInfo)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "|")
+		if len(fields) != 3 {
+			continue
+		}
+		id, err := strconv.Atoi(strings.TrimSpace(fields[1]))
+		if err != nil {
+			continue // Header line
+		}
+		vlans[strings.TrimSpace(fields[0])] = vlanInfo{id: id, parent: strings.TrimSpace(fields[2])}
+	}
+	return vlans, scanner.Err()
+}
+
+// NewNetworkGraphCollector reports the network device graph
+func NewNetworkGraphCollector(root Root) Collector {
+	return NewCollector("network_graph", linuxOnly, func(ctx context.Context) (interface{}, error) {
+		return NetworkGraph(root)
+	})
+}
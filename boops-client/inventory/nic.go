@@ -0,0 +1,188 @@
+package inventory
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// pciAddressPattern matches a PCI address such as 0000:3b:00.1
+var pciAddressPattern = regexp.MustCompile(`^[0-9a-f]{4}:[0-9a-f]{2}:[0-9a-f]{2}\.[0-7]$`)
+
+// NIC is the link-level state of a network interface
+type NIC struct {
+	Name          string `json:"name"`
+	Kind          string `json:"kind"`
+	MAC           string `json:"mac_address,omitempty"`
+	PermanentMAC  string `json:"permanent_mac_address,omitempty"`
+	MTU           int    `json:"mtu,omitempty"`
+	OperState     string `json:"operstate,omitempty"`
+	Carrier       *bool  `json:"carrier,omitempty"`
+	SpeedMbps     int    `json:"speed_mbps,omitempty"`
+	Duplex        string `json:"duplex,omitempty"`
+	Driver        string `json:"driver,omitempty"`
+	DriverVersion string `json:"driver_version,omitempty"`
+	Firmware      string `json:"firmware_version,omitempty"`
+	PCIAddress    string `json:"pci_address,omitempty"`
+}
+
+// NICs reads every interface in /sys/class/net
+func NICs(root Root) ([]NIC, error) {
+	entries, err := os.ReadDir(root.Path("sys", "class", "net"))
+	if err != nil {
+		return nil, err
+	}
+	var nics []NIC
+	for _, entry := range entries {
+		nics = append(nics, ReadNIC(root, entry.Name()))
+	}
+	sort.Slice(nics, func(i, j int) bool { return nics[i].Name < nics[j].Name })
+	return nics, nil
+}
+
+// ReadNIC reads one interface from /sys/class/net/<name>. On the live root
+// the ethtool ioctl adds the firmware version and permanent MAC, which
+// sysfs does not expose.
+func ReadNIC(root Root, name string) NIC {
+	dir := []string{"sys", "class", "net", name}
+	attr := func(elem ...string) string {
+		v, _ := root.ReadString(append(append([]string{}, dir...), elem...)...)
+		return v
+	}
+
+	nic := NIC{
+		Name:      name,
+		MAC:       attr("address"),
+		OperState: attr("operstate"),
+		Duplex:    attr("duplex"),
+	}
+	nic.MTU, _ = strconv.Atoi(attr("mtu"))
+	// carrier and speed can't be read while the interface is down, and
+	// virtual drivers report -1 for an unknown speed
+	if carrier := attr("carrier"); carrier != "" {
+		up := carrier == "1"
+		nic.Carrier = &up
+	}
+	if speed, err := strconv.Atoi(attr("speed")); err == nil && speed > 0 {
+		nic.SpeedMbps = speed
+	}
+	if nic.Duplex == "unknown" {
+		nic.Duplex = ""
+	}
+
+	if driver, err := os.Readlink(root.Path(append(dir, "device", "driver")...)); err == nil {
+		nic.Driver = filepath.Base(driver)
+		nic.DriverVersion, _ = root.ReadString("sys", "module", nic.Driver, "version")
+	}
+	// virtio and USB NICs sit below their PCI function, so take the closest
+	// PCI address on the resolved device path
+	if device, err := filepath.EvalSymlinks(root.Path(append(dir, "device")...)); err == nil {
+		for _, component := range strings.Split(device, string(filepath.Separator)) {
+			if pciAddressPattern.MatchString(component) {
+				nic.PCIAddress = component
+			}
+		}
+	}
+
+	if root.live() {
+		if info, err := ethtoolDriverInfo(name); err == nil {
+			if nic.Driver == "" {
+				nic.Driver = info.Driver
+			}
+			if info.Version != "" {
+				nic.DriverVersion = info.Version
+			}
+			nic.Firmware = info.Firmware
+		}
+		if mac, err := ethtoolPermanentAddr(name); err == nil {
+			nic.PermanentMAC = mac
+		}
+	}
+	nic.Kind = interfaceKind(root, name, nic.Driver)
+	return nic
+}
+
+// Interface kinds
+const (
+	KindLoopback  = "loopback"
+	KindPhysical  = "physical"
+	KindBond      = "bond"
+	KindBridge    = "bridge"
+	KindVLAN      = "vlan"
+	KindVeth      = "veth"
+	KindTun       = "tun"
+	KindMacvlan   = "macvlan"
+	KindWireGuard = "wireguard"
+	KindVirtual   = "virtual"
+)
+
+// kindsByDriver maps the ethtool driver name of virtual interfaces to kinds
+var kindsByDriver = map[string]string{
+	"bonding":             KindBond,
+	"bridge":              KindBridge,
+	"802.1Q VLAN Support": KindVLAN,
+	"veth":                KindVeth,
+	"tun":                 KindTun,
+	"macvlan":             KindMacvlan,
+	"wireguard":           KindWireGuard,
+}
+
+// interfaceKind classifies an interface from its sysfs entry and driver
+func interfaceKind(root Root, name, driver string) string {
+	dir := []string{"sys", "class", "net", name}
+	has := func(elem ...string) bool {
+		return root.Exists(append(append([]string{}, dir...), elem...)...)
+	}
+
+	if typ, _ := root.ReadString(append(dir, "type")...); typ == "772" { // ARPHRD_LOOPBACK
+		return KindLoopback
+	}
+	uevent, _ := root.readKeyValues("=", append(dir, "uevent")...)
+	switch uevent["DEVTYPE"] {
+	case "bond":
+		return KindBond
+	case "bridge":
+		return KindBridge
+	case "vlan":
+		return KindVLAN
+	case "wireguard":
+		return KindWireGuard
+	case "macvlan", "macvtap":
+		return KindMacvlan
+	}
+	switch {
+	case has("bonding"):
+		return KindBond
+	case has("bridge"):
+		return KindBridge
+	case has("tun_flags"):
+		return KindTun
+	case root.Exists("proc", "net", "vlan", name):
+		return KindVLAN
+	}
+	if kind, ok := kindsByDriver[driver]; ok {
+		return kind
+	}
+	if has("device") {
+		return KindPhysical
+	}
+	return KindVirtual
+}
+
+// ethtoolInfo is the subset of ETHTOOL_GDRVINFO the collector reports
+type ethtoolInfo struct {
+	Driver   string
+	Version  string
+	Firmware string
+}
+
+// NewNICCollector reports the link-level state of every interface
+func NewNICCollector(root Root) Collector {
+	return NewCollector("nics", linuxOnly, func(ctx context.Context) (interface{}, error) {
+		return NICs(root)
+	})
+}
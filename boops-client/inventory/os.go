@@ -0,0 +1,63 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// OSRelease parses /etc/os-release, falling back to /usr/lib/os-release as
+// described in os-release(5)
+func OSRelease(root Root) (map[string]string, error) {
+	values, err := root.readKeyValues("=", "etc", "os-release")
+	if err != nil {
+		values, err = root.readKeyValues("=", "usr", "lib", "os-release")
+		if err != nil {
+			return nil, err
+		}
+	}
+	for key, value := range values {
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			values[key] = unquoted
+		} else {
+			values[key] = strings.Trim(value, `'"`)
+		}
+	}
+	return values, nil
+}
+
+// OSName returns a human readable OS name, e.g. "Debian GNU/Linux 12 (bookworm)"
+func OSName(root Root) (string, error) {
+	release, err := OSRelease(root)
+	if err != nil {
+		return "", err
+	}
+	name := release["PRETTY_NAME"]
+	if name == "" {
+		name = strings.TrimSpace(release["NAME"] + " " + release["VERSION"])
+	}
+	if name == "" {
+		return "", fmt.Errorf("os-release has no NAME or PRETTY_NAME")
+	}
+	return name, nil
+}
+
+// Hostname returns the kernel hostname
+func Hostname(root Root) (string, error) {
+	return root.ReadString("proc", "sys", "kernel", "hostname")
+}
+
+// NewOSCollector reports the OS name
+func NewOSCollector(root Root) Collector {
+	return NewCollector("os", linuxOnly, func(ctx context.Context) (interface{}, error) {
+		return OSName(root)
+	})
+}
+
+// NewHostnameCollector reports the kernel hostname
+func NewHostnameCollector(root Root) Collector {
+	return NewCollector("hostname", linuxOnly, func(ctx context.Context) (interface{}, error) {
+		return Hostname(root)
+	})
+}
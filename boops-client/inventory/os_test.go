@@ -0,0 +1,64 @@
+package inventory
+
+import "testing"
+
+func TestOSName(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "pretty name",
+			files: map[string]string{
+				"etc/os-release": "NAME=\"Debian GNU/Linux\"\nVERSION=\"12 (bookworm)\"\nPRETTY_NAME=\"Debian GNU/Linux 12 (bookworm)\"\nID=debian\n",
+			},
+			want: "Debian GNU/Linux 12 (bookworm)",
+		},
+		{
+			name: "name and version",
+			files: map[string]string{
+				"etc/os-release": "NAME='Rocky Linux'\nVERSION=\"8.9 (Green Obsidian)\"\n",
+			},
+			want: "Rocky Linux 8.9 (Green Obsidian)",
+		},
+		{
+			name: "escaped quotes",
+			files: map[string]string{
+				"etc/os-release": "PRETTY_NAME=\"Example \\\"LTS\\\"\"\n",
+			},
+			want: "Example \"LTS\"",
+		},
+		{
+			name: "usr lib fallback",
+			files: map[string]string{
+				"usr/lib/os-release": "# comment\nPRETTY_NAME=\"Alpine Linux v3.19\"\n",
+			},
+			want: "Alpine Linux v3.19",
+		},
+		{
+			name: "no name",
+			files: map[string]string{
+				"etc/os-release": "ID=custom\n",
+			},
+			wantErr: true,
+		},
+		{
+			name:    "missing",
+			files:   map[string]string{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := OSName(fixtureRoot(t, tt.files))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("OSName() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("OSName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
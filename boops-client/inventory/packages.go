@@ -0,0 +1,243 @@
+package inventory
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Package is one installed package. PreviousVersion is only set on upgrades.
+type Package struct {
+	Manager         string `json:"manager"`
+	Name            string `json:"name"`
+	Version         string `json:"version"`
+	Arch            string `json:"arch,omitempty"`
+	Source          string `json:"source,omitempty"`
+	PreviousVersion string `json:"previous_version,omitempty"`
+}
+
+// key identifies a package independently of its version. Multiarch systems
+// install the same name once per architecture.
+func (p Package) key() string {
+	return p.Manager + "/" + p.Name + "/" + p.Arch
+}
+
+// dpkgStatusPath is the dpkg database, relative to the root
+var dpkgStatusPath = "var/lib/dpkg/status"
+
+// rpmQueryFormat prints one tab-separated line per package. The epoch is
+// only prefixed to the version when set, as rpm -q does.
+const rpmQueryFormat = `%{NAME}\t%|EPOCH?{%{EPOCH}:}:{}|%{VERSION}-%{RELEASE}\t%{ARCH}\t%{SOURCERPM}\n`
+
+// Packages lists the packages installed through dpkg and rpm, sorted by
+// manager, name and architecture
+func Packages(ctx context.Context, root Root) ([]Package, error) {
+	var packages []Package
+	found := false
+
+	if f, err := os.Open(root.Path(dpkgStatusPath)); err == nil {
+		dpkg, err := parseDpkgStatus(f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		packages = append(packages, dpkg...)
+		found = true
+	}
+
+	// rpm always reads the host database, so it only applies to the live root
+	if root.live() {
+		output, err := runCommand(ctx, "rpm", "-qa", "--queryformat", rpmQueryFormat)
+		switch {
+		case err == nil:
+			packages = append(packages, parseRPMQuery(output)...)
+			found = true
+		case !isNotAvailable(err):
+			return nil, err
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("package database: %w", ErrNotAvailable)
+	}
+	sort.Slice(packages, func(i, j int) bool { return packages[i].key() < packages[j].key() })
+	return packages, nil
+}
+
+// parseDpkgStatus reads the installed packages from a dpkg status file:
+// RFC 822 style stanzas separated by blank lines
+func parseDpkgStatus(r io.Reader) ([]Package, error) {
+	var packages []Package
+	fields := make(map[string]string)
+	flush := func() {
+		// Removed packages keep a stanza with "deinstall ok config-files"
+		if strings.HasSuffix(fields["Status"], " installed") && fields["Package"] != "" {
+			p := Package{
+				Manager: "dpkg",
+				Name:    fields["Package"],
+				Version: fields["Version"],
+				Arch:    fields["Architecture"],
+				Source:  fields["Package"],
+			}
+			// Source may carry its own version: "openssl (3.0.2-0ubuntu1)"
+			if source := strings.Fields(fields["Source"]); len(source) > 0 {
+				p.Source = source[0]
+			}
+			packages = append(packages, p)
+		}
+		fields = make(map[string]string)
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.TrimSpace(line) == "":
+			flush()
+		case line[0] == ' ' || line[0] == '\t':
+			// Continuation of a multi-line field such as Description
+		default:
+			if key, value, ok := strings.Cut(line, ":"); ok {
+				fields[key] = strings.TrimSpace(value)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read dpkg status: %v", err)
+	}
+	flush()
+	return packages, nil
+}
+
+// parseRPMQuery parses the output of rpm -qa with rpmQueryFormat
+func parseRPMQuery(output []byte) []Package {
+	var packages []Package
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 4 || fields[0] == "" {
+			continue
+		}
+		p := Package{Manager: "rpm", Name: fields[0], Version: fields[1], Arch: fields[2]}
+		// The source RPM is "<name>-<version>-<release>.src.rpm"; imported
+		// GPG keys have none
+		if src := fields[3]; src != "(none)" {
+			src = strings.TrimSuffix(strings.TrimSuffix(src, ".rpm"), ".src")
+			if i := strings.LastIndex(src, "-"); i > 0 {
+				src = src[:i]
+			}
+			if i := strings.LastIndex(src, "-"); i > 0 {
+				src = src[:i]
+			}
+			p.Source = src
+		}
+		if p.Arch == "(none)" {
+			p.Arch = ""
+		}
+		packages = append(packages, p)
+	}
+	return packages
+}
+
+// PackageHash fingerprints a sorted package list, so the server can tell
+// whether a delta applies to the list it has on record
+func PackageHash(packages []Package) string {
+	h := sha256.New()
+	for _, p := range packages {
+		fmt.Fprintf(h, "%s\t%s\t%s\t%s\t%s\n", p.Manager, p.Name, p.Arch, p.Version, p.Source)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// PackageUpdate is what needs uploading to bring the server's package list
+// up to date: the full list on the first run, otherwise only the packages
+// added, removed or upgraded since the last successful upload
+type PackageUpdate struct {
+	Full bool `json:"full"`
+	// Base is the hash of the list the delta applies to
+	Base     string    `json:"base,omitempty"`
+	Hash     string    `json:"hash"`
+	Count    int       `json:"count"`
+	Packages []Package `json:"packages,omitempty"`
+	Added    []Package `json:"added,omitempty"`
+	Removed  []Package `json:"removed,omitempty"`
+	Upgraded []Package `json:"upgraded,omitempty"`
+
+	current []Package
+}
+
+// Unchanged reports whether the server is already up to date
+func (u *PackageUpdate) Unchanged() bool {
+	return !u.Full && u.Base == u.Hash
+}
+
+// Commit records the list as uploaded, so the next run only sends changes
+// made after it. Call it once the server accepted the update.
+func (u *PackageUpdate) Commit() error {
+	return saveSnapshot("packages", u.current)
+}
+
+// ResetPackages forgets the last upload, so the next run sends the full
+// list again. Used when the server's list no longer matches ours.
+func ResetPackages() error {
+	err := os.Remove(filepath.Join(snapshotDir, "packages.json"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// PackageChanges compares the installed packages with the list last uploaded
+func PackageChanges(ctx context.Context, root Root) (*PackageUpdate, error) {
+	packages, err := Packages(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+	u := &PackageUpdate{Hash: PackageHash(packages), Count: len(packages), current: packages}
+
+	var previous []Package
+	if !loadSnapshot("packages", &previous) {
+		u.Full, u.Packages = true, packages
+		return u, nil
+	}
+	u.Base = PackageHash(previous)
+
+	prevByKey := make(map[string]Package, len(previous))
+	var prevKeys, curKeys []string
+	for _, p := range previous {
+		prevByKey[p.key()] = p
+		prevKeys = append(prevKeys, p.key())
+	}
+	curByKey := make(map[string]Package, len(packages))
+	for _, p := range packages {
+		curByKey[p.key()] = p
+		curKeys = append(curKeys, p.key())
+		if old, ok := prevByKey[p.key()]; ok && (old.Version != p.Version || old.Source != p.Source) {
+			p.PreviousVersion = old.Version
+			u.Upgraded = append(u.Upgraded, p)
+		}
+	}
+	added, removed := changedKeys(prevKeys, curKeys)
+	for _, k := range added {
+		u.Added = append(u.Added, curByKey[k])
+	}
+	for _, k := range removed {
+		u.Removed = append(u.Removed, prevByKey[k])
+	}
+	return u, nil
+}
+
+// NewPackageCollector reports the package changes to upload. The snapshot
+// is only advanced by PackageUpdate.Commit.
+func NewPackageCollector(root Root) Collector {
+	return NewCollector("packages", linuxOnly, func(ctx context.Context) (interface{}, error) {
+		return PackageChanges(ctx, root)
+	})
+}
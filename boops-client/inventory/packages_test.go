@@ -0,0 +1,183 @@
+package inventory
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const dpkgStatusFixture = `Package: bash
+Status: install ok installed
+Priority: required
+Architecture: amd64
+Version: 5.1-6ubuntu1
+Description: GNU Bourne Again SHell
+ Bash is an sh-compatible command language interpreter.
+ .
+ Package: not-a-package
+
+Package: libssl3
+Status: install ok installed
+Architecture: amd64
+Source: openssl (3.0.2-0ubuntu1.10)
+Version: 3.0.2-0ubuntu1.10
+
+Package: libssl3
+Status: install ok installed
+Architecture: i386
+Source: openssl (3.0.2-0ubuntu1.10)
+Version: 3.0.2-0ubuntu1.10
+
+Package: nano
+Status: deinstall ok config-files
+Architecture: amd64
+Version: 6.2-1
+
+Package: tzdata
+Status: install ok installed
+Architecture: all
+Version: 2023c-0ubuntu0.22.04.2`
+
+func TestParseDpkgStatus(t *testing.T) {
+	want := []Package{
+		{Manager: "dpkg", Name: "bash", Version: "5.1-6ubuntu1", Arch: "amd64", Source: "bash"},
+		{Manager: "dpkg", Name: "libssl3", Version: "3.0.2-0ubuntu1.10", Arch: "amd64", Source: "openssl"},
+		{Manager: "dpkg", Name: "libssl3", Version: "3.0.2-0ubuntu1.10", Arch: "i386", Source: "openssl"},
+		{Manager: "dpkg", Name: "tzdata", Version: "2023c-0ubuntu0.22.04.2", Arch: "all", Source: "tzdata"},
+	}
+	got, err := parseDpkgStatus(strings.NewReader(dpkgStatusFixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseDpkgStatus() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseRPMQuery(t *testing.T) {
+	output := strings.Join([]string{
+		"openssl\t1:3.0.7-27.el9\tx86_64\topenssl-3.0.7-27.el9.src.rpm",
+		"bash\t5.1.8-6.el9_1\tx86_64\tbash-5.1.8-6.el9_1.src.rpm",
+		"python3-dnf-plugins-core\t4.3.0-5.el9\tnoarch\tdnf-plugins-core-4.3.0-5.el9.src.rpm",
+		"gpg-pubkey\tfd431d51-4ae0493b\t(none)\t(none)",
+		"",
+		"truncated\tline",
+	}, "\n")
+	want := []Package{
+		{Manager: "rpm", Name: "openssl", Version: "1:3.0.7-27.el9", Arch: "x86_64", Source: "openssl"},
+		{Manager: "rpm", Name: "bash", Version: "5.1.8-6.el9_1", Arch: "x86_64", Source: "bash"},
+		{Manager: "rpm", Name: "python3-dnf-plugins-core", Version: "4.3.0-5.el9", Arch: "noarch", Source: "dnf-plugins-core"},
+		{Manager: "rpm", Name: "gpg-pubkey", Version: "fd431d51-4ae0493b"},
+	}
+	if got := parseRPMQuery([]byte(output)); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseRPMQuery() = %+v, want %+v", got, want)
+	}
+}
+
+func TestPackageHash(t *testing.T) {
+	packages := []Package{
+		{Manager: "dpkg", Name: "bash", Version: "5.1-6ubuntu1", Arch: "amd64", Source: "bash"},
+		{Manager: "rpm", Name: "openssl", Version: "1:3.0.7-27.el9", Arch: "x86_64", Source: "openssl"},
+	}
+	// The server keeps the hash it was sent and compares the next delta's
+	// base against it, so the format must not change between releases
+	const want = "ed609b93a48f32023a731e5d7e4a0f7e9b185c2ad2c0d91f10887eef80edeb2c"
+	if got := PackageHash(packages); got != want {
+		t.Errorf("PackageHash() = %s, want %s", got, want)
+	}
+
+	upgraded := append([]Package{}, packages...)
+	upgraded[0].PreviousVersion = "5.1-6"
+	if got := PackageHash(upgraded); got != want {
+		t.Errorf("PackageHash() with PreviousVersion = %s, want %s", got, want)
+	}
+	upgraded[0].Version = "5.1-6ubuntu2"
+	if got := PackageHash(upgraded); got == want {
+		t.Error("PackageHash() unchanged after a version change")
+	}
+	if got := PackageHash([]Package{packages[1], packages[0]}); got == want {
+		t.Error("PackageHash() unchanged after reordering")
+	}
+}
+
+func TestPackageChanges(t *testing.T) {
+	dir := snapshotDir
+	snapshotDir = t.TempDir()
+	defer func() { snapshotDir = dir }()
+
+	root := fixtureRoot(t, map[string]string{dpkgStatusPath: dpkgStatusFixture})
+	ctx := context.Background()
+
+	first, err := PackageChanges(ctx, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !first.Full || first.Count != 4 || len(first.Packages) != 4 || first.Base != "" {
+		t.Fatalf("PackageChanges() first run = %+v, want the full list", first)
+	}
+	if err := first.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	again, err := PackageChanges(ctx, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The hash survives the snapshot round trip, or the server would reject
+	// every delta as based on a list it does not have
+	if !again.Unchanged() || again.Base != first.Hash || again.Hash != first.Hash {
+		t.Fatalf("PackageChanges() without changes = %+v, want unchanged with base %s", again, first.Hash)
+	}
+
+	// bash upgraded, tzdata removed, curl added
+	status := strings.Replace(dpkgStatusFixture, "Version: 5.1-6ubuntu1", "Version: 5.1-6ubuntu1.1", 1)
+	status = status[:strings.Index(status, "Package: tzdata")] + `Package: curl
+Status: install ok installed
+Architecture: amd64
+Version: 7.81.0-1ubuntu1.13
+`
+	if err := os.WriteFile(root.Path(dpkgStatusPath), []byte(status), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	delta, err := PackageChanges(ctx, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &PackageUpdate{
+		Base:     first.Hash,
+		Hash:     delta.Hash,
+		Count:    4,
+		Added:    []Package{{Manager: "dpkg", Name: "curl", Version: "7.81.0-1ubuntu1.13", Arch: "amd64", Source: "curl"}},
+		Removed:  []Package{{Manager: "dpkg", Name: "tzdata", Version: "2023c-0ubuntu0.22.04.2", Arch: "all", Source: "tzdata"}},
+		Upgraded: []Package{{Manager: "dpkg", Name: "bash", Version: "5.1-6ubuntu1.1", Arch: "amd64", Source: "bash", PreviousVersion: "5.1-6ubuntu1"}},
+		current:  delta.current,
+	}
+	if !reflect.DeepEqual(delta, want) {
+		t.Errorf("PackageChanges() = %+v, want %+v", delta, want)
+	}
+	if delta.Unchanged() || delta.Hash == first.Hash {
+		t.Errorf("PackageChanges() hash %s unchanged after changes", delta.Hash)
+	}
+
+	// After a 409 the next run starts over with the full list
+	for i := 0; i < 2; i++ {
+		if err := ResetPackages(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reset, err := PackageChanges(ctx, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reset.Full || reset.Hash != delta.Hash {
+		t.Errorf("PackageChanges() after ResetPackages = %+v, want the full list", reset)
+	}
+}
+
+func TestPackagesNotAvailable(t *testing.T) {
+	if _, err := Packages(context.Background(), fixtureRoot(t, nil)); !isNotAvailable(err) {
+		t.Errorf("Packages() error = %v, want not available", err)
+	}
+}
@@ -0,0 +1,99 @@
+package inventory
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PCIDevice is one function on the PCI bus
+type PCIDevice struct {
+	Slot            string `json:"slot"`
+	Class           string `json:"class"`
+	ClassName       string `json:"class_name,omitempty"`
+	VendorID        string `json:"vendor_id"`
+	Vendor          string `json:"vendor,omitempty"`
+	DeviceID        string `json:"device_id"`
+	Device          string `json:"device,omitempty"`
+	SubsystemVendor string `json:"subsystem_vendor_id,omitempty"`
+	SubsystemDevice string `json:"subsystem_device_id,omitempty"`
+	Subsystem       string `json:"subsystem,omitempty"`
+	Revision        string `json:"revision,omitempty"`
+	Driver          string `json:"driver,omitempty"`
+	NUMANode        *int   `json:"numa_node,omitempty"`
+	LinkWidth       string `json:"link_width,omitempty"`
+	LinkSpeed       string `json:"link_speed,omitempty"`
+	MaxLinkWidth    string `json:"max_link_width,omitempty"`
+	MaxLinkSpeed    string `json:"max_link_speed,omitempty"`
+}
+
+// PCIDevices walks /sys/bus/pci/devices. Names are resolved from pci.ids
+// when the file is installed; the IDs are always reported.
+func PCIDevices(root Root) ([]PCIDevice, error) {
+	entries, err := os.ReadDir(root.Path("sys", "bus", "pci", "devices"))
+	if err != nil {
+		return nil, err
+	}
+	ids, _ := LoadPCIIDs(root)
+
+	var devices []PCIDevice
+	for _, entry := range entries {
+		devices = append(devices, pciDevice(root, ids, entry.Name()))
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].Slot < devices[j].Slot })
+	return devices, nil
+}
+
+func pciDevice(root Root, ids *PCIIDs, slot string) PCIDevice {
+	dir := []string{"sys", "bus", "pci", "devices", slot}
+	attr := func(name string) string {
+		v, _ := root.ReadString(append(append([]string{}, dir...), name)...)
+		return v
+	}
+	hexID := func(name string) string {
+		return strings.ToLower(strings.TrimPrefix(attr(name), "0x"))
+	}
+
+	dev := PCIDevice{
+		Slot:            slot,
+		Class:           hexID("class"),
+		VendorID:        hexID("vendor"),
+		DeviceID:        hexID("device"),
+		SubsystemVendor: hexID("subsystem_vendor"),
+		SubsystemDevice: hexID("subsystem_device"),
+		Revision:        hexID("revision"),
+		LinkWidth:       attr("current_link_width"),
+		LinkSpeed:       attr("current_link_speed"),
+		MaxLinkWidth:    attr("max_link_width"),
+		MaxLinkSpeed:    attr("max_link_speed"),
+	}
+	if driver, err := os.Readlink(root.Path(append(dir, "driver")...)); err == nil {
+		dev.Driver = filepath.Base(driver)
+	}
+	if node, err := strconv.Atoi(attr("numa_node")); err == nil && node >= 0 {
+		dev.NUMANode = &node
+	}
+	// Links that are down or unknown are reported as 0 / "Unknown"
+	if dev.LinkWidth == "0" {
+		dev.LinkWidth = ""
+	}
+	if strings.HasPrefix(dev.LinkSpeed, "Unknown") {
+		dev.LinkSpeed = ""
+	}
+
+	dev.ClassName = ids.Class(dev.Class)
+	dev.Vendor = ids.Vendor(dev.VendorID)
+	dev.Device = ids.Device(dev.VendorID, dev.DeviceID)
+	dev.Subsystem = ids.Subsystem(dev.VendorID, dev.DeviceID, dev.SubsystemVendor, dev.SubsystemDevice)
+	return dev
+}
+
+// NewPCICollector reports the PCI devices
+func NewPCICollector(root Root) Collector {
+	return NewCollector("pci", linuxOnly, func(ctx context.Context) (interface{}, error) {
+		return PCIDevices(root)
+	})
+}
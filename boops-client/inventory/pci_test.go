@@ -0,0 +1,145 @@
+package inventory
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testPCIIDs = `# pci.ids fixture
+#	List of PCI ID's
+8086  Intel Corporation
+	1521  I350 Gigabit Network Connection
+		8086 00a1  Ethernet Server Adapter I350-T4
+		15d9 1521  Supermicro I350
+	2f00  Xeon E7 v3/Xeon E5 v3/Core i7 DMI2
+15b3  Mellanox Technologies
+	1017  MT27800 Family [ConnectX-5]
+bad line without a double space
+C 02  Network controller
+	00  Ethernet controller
+	07  Infiniband controller
+C 06  Bridge
+	00  Host bridge
+L 0000  Afar
+`
+
+func TestParsePCIIDs(t *testing.T) {
+	ids, err := parsePCIIDs(strings.NewReader(testPCIIDs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"vendor", ids.Vendor("8086"), "Intel Corporation"},
+		{"second vendor", ids.Vendor("15b3"), "Mellanox Technologies"},
+		{"unknown vendor", ids.Vendor("1af4"), ""},
+		{"device", ids.Device("8086", "1521"), "I350 Gigabit Network Connection"},
+		{"device after subsystems", ids.Device("8086", "2f00"), "Xeon E7 v3/Xeon E5 v3/Core i7 DMI2"},
+		{"device of other vendor", ids.Device("15b3", "1521"), ""},
+		{"subsystem", ids.Subsystem("8086", "1521", "8086", "00a1"), "Ethernet Server Adapter I350-T4"},
+		{"other subsystem", ids.Subsystem("8086", "1521", "15d9", "1521"), "Supermicro I350"},
+		{"class", ids.Class("06"), "Bridge"},
+		{"subclass", ids.Class("020700"), "Infiniband controller"},
+		{"unknown subclass", ids.Class("0280"), "Network controller"},
+		{"short class", ids.Class("0"), ""},
+		{"later section is not a vendor", ids.Vendor("0000"), ""},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+
+	var none *PCIIDs
+	if got := none.Device("8086", "1521"); got != "" {
+		t.Errorf("nil PCIIDs Device() = %q, want empty", got)
+	}
+}
+
+func TestPCIDevices(t *testing.T) {
+	const nic = "sys/bus/pci/devices/0000:03:00.0/"
+	const bridge = "sys/bus/pci/devices/0000:00:00.0/"
+	root := fixtureRoot(t, map[string]string{
+		"usr/share/hwdata/pci.ids":    testPCIIDs,
+		nic + "class":                 "0x020000\n",
+		nic + "vendor":                "0x8086\n",
+		nic + "device":                "0x1521\n",
+		nic + "subsystem_vendor":      "0x8086\n",
+		nic + "subsystem_device":      "0x00A1\n",
+		nic + "revision":              "0x01\n",
+		nic + "numa_node":             "1\n",
+		nic + "current_link_width":    "4\n",
+		nic + "current_link_speed":    "5.0 GT/s PCIe\n",
+		nic + "max_link_width":        "4\n",
+		nic + "max_link_speed":        "5.0 GT/s PCIe\n",
+		bridge + "class":              "0x060000\n",
+		bridge + "vendor":             "0x8086\n",
+		bridge + "device":             "0x2f00\n",
+		bridge + "numa_node":          "-1\n",
+		bridge + "current_link_width": "0\n",
+		bridge + "current_link_speed": "Unknown\n",
+	})
+	if err := os.Symlink("../../../../bus/pci/drivers/igb", root.Path("sys", "bus", "pci", "devices", "0000:03:00.0", "driver")); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := PCIDevices(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	node := 1
+	want := []PCIDevice{
+		{
+			Slot:      "0000:00:00.0",
+			Class:     "060000",
+			ClassName: "Host bridge",
+			VendorID:  "8086",
+			Vendor:    "Intel Corporation",
+			DeviceID:  "2f00",
+			Device:    "Xeon E7 v3/Xeon E5 v3/Core i7 DMI2",
+		},
+		{
+			Slot:            "0000:03:00.0",
+			Class:           "020000",
+			ClassName:       "Ethernet controller",
+			VendorID:        "8086",
+			Vendor:          "Intel Corporation",
+			DeviceID:        "1521",
+			Device:          "I350 Gigabit Network Connection",
+			SubsystemVendor: "8086",
+			SubsystemDevice: "00a1",
+			Subsystem:       "Ethernet Server Adapter I350-T4",
+			Revision:        "01",
+			Driver:          "igb",
+			NUMANode:        &node,
+			LinkWidth:       "4",
+			LinkSpeed:       "5.0 GT/s PCIe",
+			MaxLinkWidth:    "4",
+			MaxLinkSpeed:    "5.0 GT/s PCIe",
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PCIDevices() = %+v, want %+v", got, want)
+	}
+}
+
+func TestPCIDevicesWithoutPCIIDs(t *testing.T) {
+	root := fixtureRoot(t, map[string]string{
+		"sys/bus/pci/devices/0000:03:00.0/class":  "0x020000\n",
+		"sys/bus/pci/devices/0000:03:00.0/vendor": "0x8086\n",
+		"sys/bus/pci/devices/0000:03:00.0/device": "0x1521\n",
+	})
+	got, err := PCIDevices(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []PCIDevice{{Slot: "0000:03:00.0", Class: "020000", VendorID: "8086", DeviceID: "1521"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PCIDevices() = %+v, want %+v", got, want)
+	}
+}
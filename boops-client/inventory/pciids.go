@@ -0,0 +1,129 @@
+package inventory
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+)
+
+// pciIDsPaths are the usual locations of the pci.ids database, relative to
+// the root
+var pciIDsPaths = [][]string{
+	{"usr", "share", "hwdata", "pci.ids"},
+	{"usr", "share", "misc", "pci.ids"},
+	{"usr", "share", "pci.ids"},
+}
+
+// PCIIDs resolves PCI vendor, device, subsystem and class IDs to names. IDs
+// are lowercase hex without the 0x prefix.
+type PCIIDs struct {
+	vendors    map[string]string
+	devices    map[string]string // vendor:device
+	subsystems map[string]string // vendor:device:subvendor:subdevice
+	classes    map[string]string // class, class:subclass
+}
+
+// LoadPCIIDs parses the first pci.ids file found below root
+func LoadPCIIDs(root Root) (*PCIIDs, error) {
+	var lastErr error
+	for _, path := range pciIDsPaths {
+		f, err := os.Open(root.Path(path...))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		defer f.Close()
+		return parsePCIIDs(f)
+	}
+	return nil, lastErr
+}
+
+func parsePCIIDs(r io.Reader) (*PCIIDs, error) {
+	ids := &PCIIDs{
+		vendors:    make(map[string]string),
+		devices:    make(map[string]string),
+		subsystems: make(map[string]string),
+		classes:    make(map[string]string),
+	}
+
+	// The file is a two-section tree indented with tabs: vendors with their
+	// devices and subsystems, then "C" class lines with their subclasses
+	var vendor, device, class string
+	inClasses := false
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		depth := len(line) - len(strings.TrimLeft(line, "\t"))
+		id, name, ok := strings.Cut(strings.TrimLeft(line, "\t"), "  ")
+		if !ok {
+			continue
+		}
+		name = strings.TrimSpace(name)
+
+		switch {
+		case depth == 0 && strings.HasPrefix(id, "C "):
+			inClasses = true
+			class = strings.TrimPrefix(id, "C ")
+			ids.classes[class] = name
+		case depth == 0:
+			// Other top-level sections (device classes, languages) follow the
+			// classes; only vendor lines are 4 hex digits
+			inClasses = false
+			vendor = ""
+			if len(id) == 4 {
+				vendor = id
+				ids.vendors[vendor] = name
+			}
+		case inClasses && depth == 1:
+			ids.classes[class+":"+id] = name
+		case vendor != "" && depth == 1:
+			device = id
+			ids.devices[vendor+":"+device] = name
+		case vendor != "" && depth == 2:
+			// "subvendor subdevice  name"
+			ids.subsystems[vendor+":"+device+":"+strings.Replace(id, " ", ":", 1)] = name
+		}
+	}
+	return ids, scanner.Err()
+}
+
+// Vendor returns the vendor name for a vendor ID
+func (p *PCIIDs) Vendor(vendor string) string {
+	if p == nil {
+		return ""
+	}
+	return p.vendors[vendor]
+}
+
+// Device returns the device name for a vendor/device ID pair
+func (p *PCIIDs) Device(vendor, device string) string {
+	if p == nil {
+		return ""
+	}
+	return p.devices[vendor+":"+device]
+}
+
+// Subsystem returns the name of a subsystem (the board built around a chip)
+func (p *PCIIDs) Subsystem(vendor, device, subVendor, subDevice string) string {
+	if p == nil {
+		return ""
+	}
+	return p.subsystems[vendor+":"+device+":"+subVendor+":"+subDevice]
+}
+
+// Class returns the most specific name for a class code such as "0200"
+func (p *PCIIDs) Class(code string) string {
+	if p == nil || len(code) < 2 {
+		return ""
+	}
+	if len(code) >= 4 {
+		if name, ok := p.classes[code[:2]+":"+code[2:4]]; ok {
+			return name
+		}
+	}
+	return p.classes[code[:2]]
+}
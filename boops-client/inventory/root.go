@@ -0,0 +1,75 @@
+// Package inventory collects hardware and software inventory by reading
+// kernel and OS interfaces (/proc, /sys, /etc) directly instead of shelling
+// out to tools that minimal images and containers often lack.
+package inventory
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Root is the filesystem root the collectors read from: "/" on a live host,
+// or a directory holding a captured fixture tree
+type Root string
+
+// Path joins elem onto the root
+func (r Root) Path(elem ...string) string {
+	return filepath.Join(append([]string{string(r)}, elem...)...)
+}
+
+// ReadString returns the trimmed contents of a file below the root
+func (r Root) ReadString(elem ...string) (string, error) {
+	data, err := os.ReadFile(r.Path(elem...))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// Exists reports whether a file or directory exists below the root
+func (r Root) Exists(elem ...string) bool {
+	_, err := os.Stat(r.Path(elem...))
+	return err == nil
+}
+
+// live reports whether the root is the running system rather than a fixture
+// tree, i.e. whether syscalls about the host agree with the files below it
+func (r Root) live() bool {
+	return filepath.Clean(string(r)) == "/"
+}
+
+// readUint reads a file below the root holding a single decimal number
+func (r Root) readUint(elem ...string) (uint64, error) {
+	s, err := r.ReadString(elem...)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
+
+// readKeyValues parses "key<sep>value" lines such as those in /proc/meminfo,
+// keeping the first occurrence of each key
+func (r Root) readKeyValues(sep string, elem ...string) (map[string]string, error) {
+	f, err := os.Open(r.Path(elem...))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), sep)
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		if _, seen := values[key]; !seen {
+			values[key] = strings.TrimSpace(value)
+		}
+	}
+	return values, scanner.Err()
+}
@@ -0,0 +1,169 @@
+package inventory
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Route is one entry of the kernel's main routing table
+type Route struct {
+	Family      string `json:"family"`
+	Interface   string `json:"interface"`
+	Destination string `json:"destination"`
+	Gateway     string `json:"gateway,omitempty"`
+	Metric      int    `json:"metric"`
+}
+
+// Default reports whether r is a default route
+func (r Route) Default() bool {
+	return r.Destination == "0.0.0.0/0" || r.Destination == "::/0"
+}
+
+// Route flags from linux/route.h
+const (
+	rtfUp     = 0x0001
+	rtfReject = 0x0200
+	rtfLocal  = 0x80000000 // IPv6 only: the host's own addresses
+)
+
+// Routes reads the IPv4 and IPv6 routes from /proc/net/route and
+// /proc/net/ipv6_route, skipping loopback and unreachable entries
+func Routes(root Root) ([]Route, error) {
+	routes, err := ipv4Routes(root)
+	if err != nil {
+		return nil, err
+	}
+	v6, err := ipv6Routes(root)
+	if err != nil && !os.IsNotExist(err) { // IPv6 may be disabled
+		return nil, err
+	}
+	return append(routes, v6...), nil
+}
+
+func ipv4Routes(root Root) ([]Route, error) {
+	f, err := os.Open(root.Path("proc", "net", "route"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var routes []Route
+	scanner := bufio.NewScanner(f)
+	scanner.Scan() // Skip the header line
+	for scanner.Scan() {
+		// Iface Destination Gateway Flags RefCnt Use Metric Mask MTU Window IRTT
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 8 {
+			continue
+		}
+		flags, _ := strconv.ParseUint(fields[3], 16, 32)
+		if flags&rtfUp == 0 || flags&rtfReject != 0 {
+			continue
+		}
+		dst, err1 := parseProcRouteAddr(fields[1])
+		gw, err2 := parseProcRouteAddr(fields[2])
+		mask, err3 := parseProcRouteAddr(fields[7])
+		if err1 != nil || err2 != nil || err3 != nil {
+			return nil, fmt.Errorf("invalid route line %q", scanner.Text())
+		}
+		ones, _ := net.IPMask(mask).Size()
+		metric, _ := strconv.Atoi(fields[6])
+		route := Route{
+			Family:      "inet",
+			Interface:   fields[0],
+			Destination: fmt.Sprintf("%s/%d", dst, ones),
+			Metric:      metric,
+		}
+		if !gw.Equal(net.IPv4zero) {
+			route.Gateway = gw.String()
+		}
+		routes = append(routes, route)
+	}
+	return routes, scanner.Err()
+}
+
+// parseProcRouteAddr decodes the host byte order hex address used by
+// /proc/net/route
+func parseProcRouteAddr(s string) (net.IP, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != 4 {
+		return nil, fmt.Errorf("invalid route address %q", s)
+	}
+	ip := make(net.IP, 4)
+	binary.NativeEndian.PutUint32(ip, binary.BigEndian.Uint32(b))
+	return ip, nil
+}
+
+func ipv6Routes(root Root) ([]Route, error) {
+	f, err := os.Open(root.Path("proc", "net", "ipv6_route"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var routes []Route
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// dest dest_plen src src_plen next_hop metric refcnt use flags iface
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 || fields[9] == "lo" {
+			continue
+		}
+		flags, _ := strconv.ParseUint(fields[8], 16, 32)
+		if flags&rtfUp == 0 || flags&(rtfReject|rtfLocal) != 0 {
+			continue
+		}
+		dst, err1 := hex.DecodeString(fields[0])
+		plen, err2 := strconv.ParseUint(fields[1], 16, 8)
+		gw, err3 := hex.DecodeString(fields[4])
+		metric, err4 := strconv.ParseUint(fields[5], 16, 32)
+		if err1 != nil || err2 != nil || err3 != nil || err4 != nil || len(dst) != 16 || len(gw) != 16 {
+			return nil, fmt.Errorf("invalid IPv6 route line %q", scanner.Text())
+		}
+		dstIP := net.IP(dst)
+		if dstIP.IsMulticast() {
+			continue
+		}
+		route := Route{
+			Family:      "inet6",
+			Interface:   fields[9],
+			Destination: fmt.Sprintf("%s/%d", dstIP, plen),
+			Metric:      int(metric),
+		}
+		if gwIP := net.IP(gw); !gwIP.Equal(net.IPv6zero) {
+			route.Gateway = gwIP.String()
+		}
+		routes = append(routes, route)
+	}
+	return routes, scanner.Err()
+}
+
+// DefaultGateways returns, per interface, the gateway of its lowest-metric
+// default route of the given family ("inet" or "inet6")
+func DefaultGateways(routes []Route, family string) map[string]string {
+	gateways := make(map[string]string)
+	metrics := make(map[string]int)
+	for _, r := range routes {
+		if r.Family != family || !r.Default() || r.Gateway == "" {
+			continue
+		}
+		if m, ok := metrics[r.Interface]; !ok || r.Metric < m {
+			gateways[r.Interface], metrics[r.Interface] = r.Gateway, r.Metric
+		}
+	}
+	return gateways
+}
+
+// NewRouteCollector reports the routing table
+func NewRouteCollector(root Root) Collector {
+	return NewCollector("routes", linuxOnly, func(ctx context.Context) (interface{}, error) {
+		return Routes(root)
+	})
+}
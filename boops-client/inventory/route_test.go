@@ -0,0 +1,88 @@
+package inventory
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// procNetHex encodes ip the way /proc/net prints addresses: each 32-bit word
+// as it sits in memory, printed as a host byte order number
+func procNetHex(ip string) string {
+	b := net.ParseIP(ip)
+	if v4 := b.To4(); v4 != nil {
+		b = v4
+	}
+	var s strings.Builder
+	for i := 0; i < len(b); i += 4 {
+		fmt.Fprintf(&s, "%08X", binary.NativeEndian.Uint32(b[i:]))
+	}
+	return s.String()
+}
+
+func procRouteLine(iface, dst, gw, flags, metric, mask string) string {
+	return fmt.Sprintf("%s\t%s\t%s\t%s\t0\t0\t%s\t%s\t0\t0\t0", iface, procNetHex(dst), procNetHex(gw), flags, metric, procNetHex(mask))
+}
+
+func TestRoutes(t *testing.T) {
+	route := strings.Join([]string{
+		"Iface\tDestination\tGateway \tFlags\tRefCnt\tUse\tMetric\tMask\t\tMTU\tWindow\tIRTT",
+		procRouteLine("eth0", "0.0.0.0", "10.0.0.1", "0003", "100", "0.0.0.0"),
+		procRouteLine("eth0", "0.0.0.0", "10.0.0.254", "0003", "200", "0.0.0.0"),
+		procRouteLine("eth0", "10.0.0.0", "0.0.0.0", "0001", "100", "255.255.255.0"),
+		procRouteLine("wlan0", "0.0.0.0", "192.168.1.1", "0003", "600", "0.0.0.0"),
+		procRouteLine("eth0", "10.9.0.0", "0.0.0.0", "0201", "0", "255.255.0.0"),
+		procRouteLine("eth1", "10.8.0.0", "0.0.0.0", "0000", "0", "255.255.0.0"),
+	}, "\n") + "\n"
+	ipv6Route := strings.Join([]string{
+		"00000000000000000000000000000000 00 00000000000000000000000000000000 00 fe800000000000000000000000000001 00000400 00000001 00000000 00000003     eth0",
+		"20010db8000000000000000000000000 40 00000000000000000000000000000000 00 00000000000000000000000000000000 00000100 00000001 00000000 00000001     eth0",
+		"20010db8000000000000000000000005 80 00000000000000000000000000000000 00 00000000000000000000000000000000 00000000 00000002 00000000 80200001     eth0",
+		"ff000000000000000000000000000000 08 00000000000000000000000000000000 00 00000000000000000000000000000000 00000100 00000003 00000000 00000001     eth0",
+		"00000000000000000000000000000001 80 00000000000000000000000000000000 00 00000000000000000000000000000000 00000000 00000002 00000000 80200001       lo",
+	}, "\n") + "\n"
+
+	want := []Route{
+		{Family: "inet", Interface: "eth0", Destination: "0.0.0.0/0", Gateway: "10.0.0.1", Metric: 100},
+		{Family: "inet", Interface: "eth0", Destination: "0.0.0.0/0", Gateway: "10.0.0.254", Metric: 200},
+		{Family: "inet", Interface: "eth0", Destination: "10.0.0.0/24", Metric: 100},
+		{Family: "inet", Interface: "wlan0", Destination: "0.0.0.0/0", Gateway: "192.168.1.1", Metric: 600},
+		{Family: "inet6", Interface: "eth0", Destination: "::/0", Gateway: "fe80::1", Metric: 1024},
+		{Family: "inet6", Interface: "eth0", Destination: "2001:db8::/64", Metric: 256},
+	}
+
+	root := fixtureRoot(t, map[string]string{"proc/net/route": route, "proc/net/ipv6_route": ipv6Route})
+	got, err := Routes(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Routes() = %+v, want %+v", got, want)
+	}
+
+	// IPv6 may be disabled
+	got, err = Routes(fixtureRoot(t, map[string]string{"proc/net/route": route}))
+	if err != nil || len(got) != 4 {
+		t.Errorf("Routes() without ipv6_route = %+v, %v, want the 4 IPv4 routes", got, err)
+	}
+
+	if got, want := DefaultGateways(want, "inet"), map[string]string{"eth0": "10.0.0.1", "wlan0": "192.168.1.1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DefaultGateways(inet) = %v, want %v", got, want)
+	}
+	if got, want := DefaultGateways(want, "inet6"), map[string]string{"eth0": "fe80::1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DefaultGateways(inet6) = %v, want %v", got, want)
+	}
+}
+
+func TestRoutesInvalid(t *testing.T) {
+	root := fixtureRoot(t, map[string]string{
+		"proc/net/route": "Iface\tDestination\tGateway\tFlags\tRefCnt\tUse\tMetric\tMask\n" +
+			"eth0\tXYZ\t00000000\t0001\t0\t0\t0\t00000000\n",
+	})
+	if routes, err := Routes(root); err == nil {
+		t.Errorf("Routes() = %+v, want an error", routes)
+	}
+}
@@ -0,0 +1,141 @@
+package inventory
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// SMBIOS structure types used by the collectors
+const (
+	smbiosTypeSystem  = 1
+	smbiosTypeBoard   = 2
+	smbiosTypeChassis = 3
+	smbiosTypeEnd     = 127
+)
+
+// SMBIOSStructure is one raw structure from the SMBIOS table: the formatted
+// area (header included) and its string set
+type SMBIOSStructure struct {
+	Type      uint8
+	Handle    uint16
+	Formatted []byte
+	Strings   []string
+}
+
+// Byte returns the byte at offset in the formatted area
+func (s SMBIOSStructure) Byte(offset int) (uint8, bool) {
+	if offset >= len(s.Formatted) {
+		return 0, false
+	}
+	return s.Formatted[offset], true
+}
+
+// Word returns the little-endian uint16 at offset in the formatted area
+func (s SMBIOSStructure) Word(offset int) (uint16, bool) {
+	if offset+2 > len(s.Formatted) {
+		return 0, false
+	}
+	return binary.LittleEndian.Uint16(s.Formatted[offset:]), true
+}
+
+// DWord returns the little-endian uint32 at offset in the formatted area
+func (s SMBIOSStructure) DWord(offset int) (uint32, bool) {
+	if offset+4 > len(s.Formatted) {
+		return 0, false
+	}
+	return binary.LittleEndian.Uint32(s.Formatted[offset:]), true
+}
+
+// String resolves the string reference stored at offset. Reference 0 and
+// out-of-range references yield "".
+func (s SMBIOSStructure) String(offset int) string {
+	idx, ok := s.Byte(offset)
+	if !ok || idx == 0 || int(idx) > len(s.Strings) {
+		return ""
+	}
+	return strings.TrimSpace(s.Strings[idx-1])
+}
+
+// ReadSMBIOS parses the raw SMBIOS structure table the kernel exports in
+// /sys/firmware/dmi/tables/DMI
+func ReadSMBIOS(root Root) ([]SMBIOSStructure, error) {
+	data, err := os.ReadFile(root.Path("sys", "firmware", "dmi", "tables", "DMI"))
+	if err != nil {
+		return nil, err
+	}
+	return parseSMBIOS(data)
+}
+
+func parseSMBIOS(data []byte) ([]SMBIOSStructure, error) {
+	var structures []SMBIOSStructure
+	for off := 0; off+4 <= len(data); {
+		length := int(data[off+1])
+		if length < 4 || off+length > len(data) {
+			return structures, fmt.Errorf("truncated SMBIOS structure at offset %d", off)
+		}
+		s := SMBIOSStructure{
+			Type:      data[off],
+			Handle:    binary.LittleEndian.Uint16(data[off+2:]),
+			Formatted: data[off : off+length],
+		}
+
+		// The string set follows the formatted area and ends with a double NUL
+		end := off + length
+		for end+1 < len(data) && !(data[end] == 0 && data[end+1] == 0) {
+			end++
+		}
+		if end+1 >= len(data) {
+			return structures, fmt.Errorf("unterminated SMBIOS string set at offset %d", off)
+		}
+		if strs := data[off+length : end]; len(strs) > 0 {
+			s.Strings = strings.Split(strings.Trim(string(strs), "\x00"), "\x00")
+		}
+
+		structures = append(structures, s)
+		if s.Type == smbiosTypeEnd {
+			break
+		}
+		off = end + 2
+	}
+	return structures, nil
+}
+
+// smbiosOfType returns every structure of the given type
+func smbiosOfType(structures []SMBIOSStructure, t uint8) []SMBIOSStructure {
+	var result []SMBIOSStructure
+	for _, s := range structures {
+		if s.Type == t {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// smbiosPlaceholders are values vendors leave in unset SMBIOS fields
+var smbiosPlaceholders = map[string]bool{
+	"to be filled by o.e.m.":   true,
+	"to be filled by oem":      true,
+	"default string":           true,
+	"not specified":            true,
+	"not applicable":           true,
+	"system serial number":     true,
+	"system product name":      true,
+	"system manufacturer":      true,
+	"chassis serial number":    true,
+	"base board serial number": true,
+	"asset-1234567890":         true,
+	"0123456789":               true,
+	"none":                     true,
+	"n/a":                      true,
+}
+
+// cleanSMBIOSString drops vendor placeholder values
+func cleanSMBIOSString(s string) string {
+	s = strings.TrimSpace(s)
+	if smbiosPlaceholders[strings.ToLower(s)] {
+		return ""
+	}
+	return s
+}
@@ -0,0 +1,70 @@
+package inventory
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// snapshotDir holds the previous run's data for collectors that report
+// changes between runs
+var snapshotDir = "/etc/boops/inventory"
+
+// loadSnapshot reads the previous run's data for name into v. It reports
+// false when there is no usable snapshot, e.g. on the first run.
+func loadSnapshot(name string, v interface{}) bool {
+	data, err := os.ReadFile(filepath.Join(snapshotDir, name+".json"))
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, v) == nil
+}
+
+// saveSnapshot stores v as the data for name, replacing the file atomically
+func saveSnapshot(name string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(snapshotDir, 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(snapshotDir, "."+name+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(snapshotDir, name+".json"))
+}
+
+// changedKeys returns the sorted keys only in cur (added) and only in prev
+// (removed)
+func changedKeys(prev, cur []string) (added, removed []string) {
+	inPrev := make(map[string]bool, len(prev))
+	for _, k := range prev {
+		inPrev[k] = true
+	}
+	inCur := make(map[string]bool, len(cur))
+	for _, k := range cur {
+		inCur[k] = true
+		if !inPrev[k] {
+			added = append(added, k)
+		}
+	}
+	for _, k := range prev {
+		if !inCur[k] {
+			removed = append(removed, k)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
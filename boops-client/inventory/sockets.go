@@ -0,0 +1,242 @@
+package inventory
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Socket is a listening TCP socket or a bound, unconnected UDP socket
+type Socket struct {
+	// Protocol is tcp, tcp6, udp or udp6, as in /proc/net
+	Protocol string `json:"protocol"`
+	Address  string `json:"address"`
+	Port     int    `json:"port"`
+	// PID, Process and Unit describe the owning process when it could be
+	// found; sockets of other users' processes need root
+	PID     int    `json:"pid,omitempty"`
+	Process string `json:"process,omitempty"`
+	Unit    string `json:"unit,omitempty"`
+
+	inode string
+}
+
+// key identifies a socket across runs, regardless of which process owns it
+func (s Socket) key() string {
+	return s.Protocol + " " + net.JoinHostPort(s.Address, strconv.Itoa(s.Port))
+}
+
+// ListeningSockets is the socket list plus what changed since the last run
+type ListeningSockets struct {
+	Sockets []Socket `json:"sockets"`
+	Opened  []Socket `json:"opened,omitempty"`
+	Closed  []Socket `json:"closed,omitempty"`
+}
+
+// Socket states from include/net/tcp_states.h
+const (
+	tcpListen = 0x0a
+	tcpClose  = 0x07
+)
+
+// Sockets lists the listening sockets with their owning processes
+func Sockets(root Root) ([]Socket, error) {
+	var sockets []Socket
+	found := false
+	for _, proto := range []string{"tcp", "tcp6", "udp", "udp6"} {
+		s, err := procNetSockets(root, proto)
+		if err != nil {
+			if isNotAvailable(err) {
+				continue // IPv6 may be disabled
+			}
+			return nil, err
+		}
+		sockets = append(sockets, s...)
+		found = true
+	}
+	if !found {
+		return nil, fmt.Errorf("/proc/net: %w", ErrNotAvailable)
+	}
+
+	owners := socketOwners(root)
+	for i := range sockets {
+		if pid, ok := owners[sockets[i].inode]; ok {
+			sockets[i].PID = pid
+			sockets[i].Process, _ = root.ReadString("proc", strconv.Itoa(pid), "comm")
+			sockets[i].Unit = systemdUnitOf(root, pid)
+		}
+	}
+	sort.Slice(sockets, func(i, j int) bool { return sockets[i].key() < sockets[j].key() })
+	return sockets, nil
+}
+
+// procNetSockets parses one of /proc/net/{tcp,tcp6,udp,udp6}
+func procNetSockets(root Root, proto string) ([]Socket, error) {
+	f, err := os.Open(root.Path("proc", "net", proto))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	udp := strings.HasPrefix(proto, "udp")
+	seen := make(map[string]bool)
+	var sockets []Socket
+	scanner := bufio.NewScanner(f)
+	scanner.Scan() // Header
+	for scanner.Scan() {
+		// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+		state, _ := strconv.ParseUint(fields[3], 16, 8)
+		if !udp && state != tcpListen {
+			continue
+		}
+		// UDP has no listen state: bound sockets without a peer are the
+		// equivalent
+		if udp && (state != tcpClose || !strings.HasSuffix(fields[2], ":0000")) {
+			continue
+		}
+		// SO_REUSEPORT groups show up once per socket
+		if seen[fields[1]] {
+			continue
+		}
+		seen[fields[1]] = true
+
+		addr, port, err := parseProcNetAddr(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse /proc/net/%s: %v", proto, err)
+		}
+		sockets = append(sockets, Socket{Protocol: proto, Address: addr.String(), Port: port, inode: fields[9]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read /proc/net/%s: %v", proto, err)
+	}
+	return sockets, nil
+}
+
+// parseProcNetAddr decodes "ADDR:PORT" from /proc/net, where the address is
+// printed as host byte order 32-bit words and the port in hex
+func parseProcNetAddr(s string) (net.IP, int, error) {
+	host, portHex, ok := strings.Cut(s, ":")
+	b, err := hex.DecodeString(host)
+	if !ok || err != nil || (len(b) != net.IPv4len && len(b) != net.IPv6len) {
+		return nil, 0, fmt.Errorf("invalid socket address %q", s)
+	}
+	port, err := strconv.ParseUint(portHex, 16, 16)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid socket port %q", s)
+	}
+	ip := make(net.IP, len(b))
+	for i := 0; i < len(b); i += 4 {
+		binary.NativeEndian.PutUint32(ip[i:], binary.BigEndian.Uint32(b[i:]))
+	}
+	return ip, int(port), nil
+}
+
+// socketOwners maps socket inodes to the lowest PID holding them open.
+// Processes that exit or can't be inspected are skipped.
+func socketOwners(root Root) map[string]int {
+	owners := make(map[string]int)
+	procs, err := os.ReadDir(root.Path("proc"))
+	if err != nil {
+		return owners
+	}
+	for _, p := range procs {
+		pid, err := strconv.Atoi(p.Name())
+		if err != nil {
+			continue
+		}
+		fds, err := os.ReadDir(root.Path("proc", p.Name(), "fd"))
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			target, err := os.Readlink(root.Path("proc", p.Name(), "fd", fd.Name()))
+			if err != nil || !strings.HasPrefix(target, "socket:[") {
+				continue
+			}
+			inode := strings.TrimSuffix(strings.TrimPrefix(target, "socket:["), "]")
+			if owner, ok := owners[inode]; !ok || pid < owner {
+				owners[inode] = pid
+			}
+		}
+	}
+	return owners
+}
+
+// systemdUnitOf returns the innermost service unit in the cgroup path of
+// pid, e.g. "nginx.service" for "0::/system.slice/nginx.service"
+func systemdUnitOf(root Root, pid int) string {
+	data, err := os.ReadFile(root.Path("proc", strconv.Itoa(pid), "cgroup"))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		// hierarchy-ID:controllers:path; the unified or name=systemd one
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 || (parts[1] != "" && parts[1] != "name=systemd") {
+			continue
+		}
+		segments := strings.Split(parts[2], "/")
+		for i := len(segments) - 1; i >= 0; i-- {
+			if strings.HasSuffix(segments[i], ".service") {
+				return segments[i]
+			}
+		}
+	}
+	return ""
+}
+
+// Listening collects the listening sockets and compares them with the
+// snapshot of the last uploaded run. The first run reports no changes.
+func Listening(root Root) (*ListeningSockets, error) {
+	sockets, err := Sockets(root)
+	if err != nil {
+		return nil, err
+	}
+	result := &ListeningSockets{Sockets: sockets}
+
+	var previous []Socket
+	if loadSnapshot("sockets", &previous) {
+		byKey := make(map[string]Socket)
+		var prevKeys, curKeys []string
+		for _, s := range previous {
+			byKey[s.key()] = s
+			prevKeys = append(prevKeys, s.key())
+		}
+		for _, s := range sockets {
+			byKey[s.key()] = s
+			curKeys = append(curKeys, s.key())
+		}
+		added, removed := changedKeys(prevKeys, curKeys)
+		for _, k := range added {
+			result.Opened = append(result.Opened, byKey[k])
+		}
+		for _, k := range removed {
+			result.Closed = append(result.Closed, byKey[k])
+		}
+	}
+	return result, nil
+}
+
+// Commit records the sockets as the baseline for the next run. Call it once
+// the inventory has been uploaded, so changes aren't lost to a failed upload.
+func (l *ListeningSockets) Commit() error {
+	return saveSnapshot("sockets", l.Sockets)
+}
+
+// NewSocketCollector reports listening sockets and opened/closed changes
+func NewSocketCollector(root Root) Collector {
+	return NewCollector("sockets", linuxOnly, func(ctx context.Context) (interface{}, error) {
+		return Listening(root)
+	})
+}
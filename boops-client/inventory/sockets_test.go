@@ -0,0 +1,189 @@
+package inventory
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const procNetHeader = "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n"
+
+func procNetLine(local string, localPort int, remote string, remotePort int, state string, inode int) string {
+	return fmt.Sprintf("   0: %s:%04X %s:%04X %s 00000000:00000000 00:00000000 00000000     0        0 %d 1 0000000000000000 100 0 0 10 0\n",
+		procNetHex(local), localPort, procNetHex(remote), remotePort, state, inode)
+}
+
+// socketFixture is a host running sshd and nginx plus an interactive shell
+func socketFixture(t *testing.T, extra map[string]string) Root {
+	t.Helper()
+	files := map[string]string{
+		"proc/net/tcp": procNetHeader +
+			procNetLine("0.0.0.0", 22, "0.0.0.0", 0, "0A", 1001) +
+			procNetLine("10.0.0.5", 22, "10.0.0.9", 51515, "01", 1009) +
+			// nginx workers share port 80 through SO_REUSEPORT
+			procNetLine("0.0.0.0", 80, "0.0.0.0", 0, "0A", 1002) +
+			procNetLine("0.0.0.0", 80, "0.0.0.0", 0, "0A", 1003),
+		"proc/net/tcp6": procNetHeader +
+			procNetLine("::", 22, "::", 0, "0A", 1004),
+		"proc/net/udp": procNetHeader +
+			procNetLine("127.0.0.53", 53, "0.0.0.0", 0, "07", 1005) +
+			procNetLine("10.0.0.5", 40000, "10.0.0.53", 53, "01", 1010) +
+			procNetLine("10.0.0.5", 40001, "10.0.0.53", 53, "07", 1011),
+		"proc/net/udp6": procNetHeader +
+			procNetLine("::1", 323, "::", 0, "07", 1006),
+		"proc/100/comm":   "sshd\n",
+		"proc/100/cgroup": "0::/system.slice/ssh.service\n",
+		"proc/150/comm":   "nginx\n",
+		"proc/150/cgroup": "12:pids:/system.slice/nginx.service\n1:name=systemd:/system.slice/nginx.service\n0::/\n",
+		"proc/200/comm":   "nginx\n",
+		"proc/300/comm":   "bash\n",
+		"proc/300/cgroup": "0::/user.slice/user-1000.slice/session-2.scope\n",
+	}
+	for name, content := range extra {
+		files[name] = content
+	}
+	root := fixtureRoot(t, files)
+	for link, target := range map[string]string{
+		"proc/100/fd/3": "socket:[1001]",
+		"proc/100/fd/4": "socket:[1004]",
+		"proc/150/fd/6": "socket:[1002]",
+		"proc/200/fd/6": "socket:[1002]",
+		"proc/300/fd/0": "/dev/pts/0",
+		"proc/300/fd/1": "socket:[1009]",
+	} {
+		path := root.Path(strings.Split(link, "/")...)
+		if err := os.MkdirAll(root.Path(strings.Split(link, "/")[:3]...), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(target, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestSockets(t *testing.T) {
+	got, err := Sockets(socketFixture(t, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Socket{
+		{Protocol: "tcp", Address: "0.0.0.0", Port: 22, PID: 100, Process: "sshd", Unit: "ssh.service", inode: "1001"},
+		{Protocol: "tcp", Address: "0.0.0.0", Port: 80, PID: 150, Process: "nginx", Unit: "nginx.service", inode: "1002"},
+		{Protocol: "tcp6", Address: "::", Port: 22, PID: 100, Process: "sshd", Unit: "ssh.service", inode: "1004"},
+		{Protocol: "udp", Address: "127.0.0.53", Port: 53, inode: "1005"},
+		{Protocol: "udp6", Address: "::1", Port: 323, inode: "1006"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Sockets() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSocketsWithoutIPv6(t *testing.T) {
+	root := fixtureRoot(t, map[string]string{
+		"proc/net/tcp": procNetHeader + procNetLine("0.0.0.0", 22, "0.0.0.0", 0, "0A", 1001),
+		"proc/net/udp": procNetHeader,
+	})
+	got, err := Sockets(root)
+	if err != nil || len(got) != 1 || got[0].key() != "tcp 0.0.0.0:22" {
+		t.Errorf("Sockets() = %+v, %v, want tcp 0.0.0.0:22", got, err)
+	}
+
+	if _, err := Sockets(fixtureRoot(t, map[string]string{})); !isNotAvailable(err) {
+		t.Errorf("Sockets() without /proc/net error = %v, want ErrNotAvailable", err)
+	}
+}
+
+func TestParseProcNetAddr(t *testing.T) {
+	tests := []struct {
+		in       string
+		wantIP   string
+		wantPort int
+		wantErr  bool
+	}{
+		{in: procNetHex("127.0.0.1") + ":0050", wantIP: "127.0.0.1", wantPort: 80},
+		{in: procNetHex("2001:db8::1") + ":01BB", wantIP: "2001:db8::1", wantPort: 443},
+		{in: procNetHex("::ffff:10.0.0.5") + ":0016", wantIP: "10.0.0.5", wantPort: 22},
+		{in: "0100007F", wantErr: true},
+		{in: "0100007F:XYZ", wantErr: true},
+		{in: "01007F:0050", wantErr: true},
+		{in: "ZZ00007F:0050", wantErr: true},
+	}
+	for _, tt := range tests {
+		ip, port, err := parseProcNetAddr(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseProcNetAddr(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if err == nil && (ip.String() != tt.wantIP || port != tt.wantPort) {
+			t.Errorf("parseProcNetAddr(%q) = %s, %d, want %s, %d", tt.in, ip, port, tt.wantIP, tt.wantPort)
+		}
+	}
+}
+
+func TestSystemdUnitOf(t *testing.T) {
+	tests := []struct {
+		cgroup string
+		want   string
+	}{
+		{"0::/system.slice/nginx.service\n", "nginx.service"},
+		{"0::/system.slice/docker-1234.scope\n", ""},
+		{"0::/system.slice/containerd.service/kubepods/pod1\n", "containerd.service"},
+		{"0::/user.slice/user-1000.slice/user@1000.service/app.slice/foo.service\n", "foo.service"},
+		{"12:pids:/system.slice/cron.service\n1:name=systemd:/system.slice/ssh.service\n", "ssh.service"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		root := fixtureRoot(t, map[string]string{"proc/42/cgroup": tt.cgroup})
+		if got := systemdUnitOf(root, 42); got != tt.want {
+			t.Errorf("systemdUnitOf(%q) = %q, want %q", tt.cgroup, got, tt.want)
+		}
+	}
+	if got := systemdUnitOf(fixtureRoot(t, nil), 42); got != "" {
+		t.Errorf("systemdUnitOf() for a missing process = %q, want empty", got)
+	}
+}
+
+func TestListening(t *testing.T) {
+	dir := snapshotDir
+	snapshotDir = t.TempDir()
+	defer func() { snapshotDir = dir }()
+
+	first, err := Listening(socketFixture(t, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first.Opened) != 0 || len(first.Closed) != 0 {
+		t.Errorf("first Listening() = %+v, want no changes", first)
+	}
+
+	// Without a commit, e.g. after a failed upload, the baseline stays empty
+	if again, _ := Listening(socketFixture(t, nil)); len(again.Opened) != 0 {
+		t.Errorf("uncommitted Listening() = %+v, want no changes", again)
+	}
+	if err := first.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	changed := socketFixture(t, map[string]string{
+		"proc/net/tcp": procNetHeader +
+			procNetLine("0.0.0.0", 22, "0.0.0.0", 0, "0A", 1001) +
+			procNetLine("0.0.0.0", 8080, "0.0.0.0", 0, "0A", 1007),
+	})
+	second, err := Listening(changed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var opened, closed []string
+	for _, s := range second.Opened {
+		opened = append(opened, s.key())
+	}
+	for _, s := range second.Closed {
+		closed = append(closed, s.key())
+	}
+	if !reflect.DeepEqual(opened, []string{"tcp 0.0.0.0:8080"}) || !reflect.DeepEqual(closed, []string{"tcp 0.0.0.0:80"}) {
+		t.Errorf("Listening() opened %q and closed %q, want tcp 0.0.0.0:8080 and tcp 0.0.0.0:80", opened, closed)
+	}
+}
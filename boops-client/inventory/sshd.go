@@ -0,0 +1,137 @@
+package inventory
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SSHDConfig is the access-related part of the OpenSSH server configuration.
+// Settings inside Match blocks only apply to some connections and are not
+// reflected.
+type SSHDConfig struct {
+	PermitRootLogin string `json:"permit_root_login"`
+	// RootLogin is false only when PermitRootLogin is "no"; the other values
+	// still allow root in with a key or a forced command
+	RootLogin              bool     `json:"root_login"`
+	PasswordAuthentication bool     `json:"password_authentication"`
+	AuthorizedKeysFile     []string `json:"authorized_keys_file"`
+	// Files lists the configuration files read, includes included
+	Files []string `json:"files"`
+	// MatchBlocks reports that some settings may differ per connection
+	MatchBlocks bool `json:"match_blocks,omitempty"`
+}
+
+// sshdConfigPath is the server configuration, relative to the root
+var sshdConfigPath = "etc/ssh/sshd_config"
+
+// sshdMaxIncludeDepth bounds Include recursion, as sshd itself does
+const sshdMaxIncludeDepth = 16
+
+// ReadSSHDConfig parses sshd_config and the files it includes. Like sshd,
+// the first value given for a keyword wins; unset keywords get the OpenSSH
+// defaults.
+func ReadSSHDConfig(root Root) (*SSHDConfig, error) {
+	values := make(map[string][]string)
+	cfg := &SSHDConfig{Files: []string{}}
+	if err := readSSHDConfigFile(root, "/"+sshdConfigPath, values, cfg, 0); err != nil {
+		return nil, err
+	}
+
+	cfg.PermitRootLogin = "prohibit-password"
+	if v := values["permitrootlogin"]; len(v) > 0 {
+		cfg.PermitRootLogin = strings.ToLower(v[0])
+		// "without-password" is the old name of prohibit-password
+		if cfg.PermitRootLogin == "without-password" {
+			cfg.PermitRootLogin = "prohibit-password"
+		}
+	}
+	cfg.RootLogin = cfg.PermitRootLogin != "no"
+	cfg.PasswordAuthentication = true
+	if v := values["passwordauthentication"]; len(v) > 0 {
+		cfg.PasswordAuthentication = strings.ToLower(v[0]) == "yes"
+	}
+	cfg.AuthorizedKeysFile = []string{".ssh/authorized_keys", ".ssh/authorized_keys2"}
+	if v := values["authorizedkeysfile"]; len(v) > 0 {
+		cfg.AuthorizedKeysFile = v
+	}
+	return cfg, nil
+}
+
+// readSSHDConfigFile reads one configuration file. path is absolute within
+// the root.
+func readSSHDConfigFile(root Root, path string, values map[string][]string, cfg *SSHDConfig, depth int) error {
+	if depth > sshdMaxIncludeDepth {
+		return fmt.Errorf("sshd_config includes nested too deeply at %s", path)
+	}
+	f, err := os.Open(root.Path(path))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	cfg.Files = append(cfg.Files, path)
+
+	// A Match block lasts until the next Match or the end of the file
+	inMatch := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// "Keyword value" or "Keyword=value"
+		keyword, rest := line, ""
+		if i := strings.IndexAny(line, " \t="); i >= 0 {
+			keyword, rest = line[:i], strings.TrimPrefix(strings.TrimLeft(line[i:], " \t"), "=")
+		}
+		keyword = strings.ToLower(keyword)
+		var args []string
+		for _, arg := range strings.Fields(rest) {
+			args = append(args, strings.Trim(arg, `"`))
+		}
+
+		switch {
+		case keyword == "match":
+			inMatch = !(len(args) == 1 && strings.ToLower(args[0]) == "all")
+			cfg.MatchBlocks = cfg.MatchBlocks || inMatch
+		case inMatch:
+		case keyword == "include":
+			for _, pattern := range args {
+				if !strings.HasPrefix(pattern, "/") {
+					pattern = "/etc/ssh/" + pattern
+				}
+				matches, err := filepath.Glob(root.Path(pattern))
+				if err != nil {
+					return fmt.Errorf("invalid Include pattern %q in %s: %v", pattern, path, err)
+				}
+				sort.Strings(matches)
+				for _, match := range matches {
+					rel := "/" + strings.TrimPrefix(match, filepath.Clean(string(root)))
+					if err := readSSHDConfigFile(root, filepath.Clean(rel), values, cfg, depth+1); err != nil {
+						return err
+					}
+				}
+			}
+		case len(args) > 0:
+			if _, set := values[keyword]; !set {
+				values[keyword] = args
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	return nil
+}
+
+// NewSSHDCollector reports whether root login and password authentication
+// are allowed
+func NewSSHDCollector(root Root) Collector {
+	return NewCollector("sshd", linuxOnly, func(ctx context.Context) (interface{}, error) {
+		return ReadSSHDConfig(root)
+	})
+}
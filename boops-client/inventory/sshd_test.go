@@ -0,0 +1,95 @@
+package inventory
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadSSHDConfig(t *testing.T) {
+	defaultKeys := []string{".ssh/authorized_keys", ".ssh/authorized_keys2"}
+	tests := []struct {
+		name    string
+		files   map[string]string
+		want    *SSHDConfig
+		wantErr bool
+	}{
+		{
+			name:  "defaults",
+			files: map[string]string{"etc/ssh/sshd_config": "# Nothing set\n\n"},
+			want: &SSHDConfig{PermitRootLogin: "prohibit-password", RootLogin: true, PasswordAuthentication: true,
+				AuthorizedKeysFile: defaultKeys, Files: []string{"/etc/ssh/sshd_config"}},
+		},
+		{
+			name:  "first value wins",
+			files: map[string]string{"etc/ssh/sshd_config": "PermitRootLogin no\nPasswordAuthentication no\npermitrootlogin yes\nPasswordAuthentication yes\n"},
+			want: &SSHDConfig{PermitRootLogin: "no", PasswordAuthentication: false,
+				AuthorizedKeysFile: defaultKeys, Files: []string{"/etc/ssh/sshd_config"}},
+		},
+		{
+			name: "includes are read in order before later lines",
+			files: map[string]string{
+				"etc/ssh/sshd_config": "Include /etc/ssh/sshd_config.d/*.conf\n" +
+					"PermitRootLogin yes\nPasswordAuthentication yes\n" +
+					"AuthorizedKeysFile .ssh/authorized_keys /etc/ssh/keys/%u\n",
+				"etc/ssh/sshd_config.d/50-cloud-init.conf": "PasswordAuthentication=no\nPermitRootLogin no\n",
+				"etc/ssh/sshd_config.d/10-hardening.conf":  "\tPermitRootLogin \"without-password\"\n",
+				"etc/ssh/sshd_config.d/README":             "PermitRootLogin no\n",
+			},
+			want: &SSHDConfig{PermitRootLogin: "prohibit-password", RootLogin: true, PasswordAuthentication: false,
+				AuthorizedKeysFile: []string{".ssh/authorized_keys", "/etc/ssh/keys/%u"},
+				Files: []string{"/etc/ssh/sshd_config", "/etc/ssh/sshd_config.d/10-hardening.conf",
+					"/etc/ssh/sshd_config.d/50-cloud-init.conf"}},
+		},
+		{
+			name: "relative include",
+			files: map[string]string{
+				"etc/ssh/sshd_config": "Include local.conf\n",
+				"etc/ssh/local.conf":  "PermitRootLogin forced-commands-only\n",
+			},
+			want: &SSHDConfig{PermitRootLogin: "forced-commands-only", RootLogin: true, PasswordAuthentication: true,
+				AuthorizedKeysFile: defaultKeys, Files: []string{"/etc/ssh/sshd_config", "/etc/ssh/local.conf"}},
+		},
+		{
+			name: "match blocks are not applied",
+			files: map[string]string{"etc/ssh/sshd_config": "PasswordAuthentication no\n" +
+				"Match User backup\n  PermitRootLogin no\n  PasswordAuthentication yes\n" +
+				"Match all\nAuthorizedKeysFile none\n"},
+			want: &SSHDConfig{PermitRootLogin: "prohibit-password", RootLogin: true, PasswordAuthentication: false,
+				AuthorizedKeysFile: []string{"none"}, Files: []string{"/etc/ssh/sshd_config"}, MatchBlocks: true},
+		},
+		{
+			name: "match in an include ends with the file",
+			files: map[string]string{
+				"etc/ssh/sshd_config": "Include /etc/ssh/match.conf\nPermitRootLogin no\n",
+				"etc/ssh/match.conf":  "Match Address 10.0.0.0/8\nPermitRootLogin yes\n",
+			},
+			want: &SSHDConfig{PermitRootLogin: "no", PasswordAuthentication: true, AuthorizedKeysFile: defaultKeys,
+				Files: []string{"/etc/ssh/sshd_config", "/etc/ssh/match.conf"}, MatchBlocks: true},
+		},
+		{
+			name:    "missing",
+			files:   map[string]string{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadSSHDConfig(fixtureRoot(t, tt.files))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadSSHDConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadSSHDConfig() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadSSHDConfigIncludeDepth(t *testing.T) {
+	_, err := ReadSSHDConfig(fixtureRoot(t, map[string]string{"etc/ssh/sshd_config": "Include /etc/ssh/sshd_config\n"}))
+	if err == nil || !strings.Contains(err.Error(), "nested too deeply") {
+		t.Errorf("ReadSSHDConfig() error = %v, want nested too deeply", err)
+	}
+}
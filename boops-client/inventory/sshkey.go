@@ -0,0 +1,67 @@
+package inventory
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// SSHKey describes a public key without its key material
+type SSHKey struct {
+	Type string `json:"type"`
+	// Fingerprint is the SHA256 fingerprint as printed by ssh-keygen -l
+	Fingerprint string `json:"fingerprint"`
+	Comment     string `json:"comment,omitempty"`
+}
+
+// sshKeyTypes are the public key algorithms OpenSSH writes in key files
+var sshKeyTypes = map[string]bool{
+	"ssh-rsa":                            true,
+	"ssh-dss":                            true,
+	"ssh-ed25519":                        true,
+	"ecdsa-sha2-nistp256":                true,
+	"ecdsa-sha2-nistp384":                true,
+	"ecdsa-sha2-nistp521":                true,
+	"sk-ssh-ed25519@openssh.com":         true,
+	"sk-ecdsa-sha2-nistp256@openssh.com": true,
+}
+
+// parseSSHPublicKey parses a public key line, as in authorized_keys or a
+// *.pub file, and returns the key and its decoded blob. Leading
+// authorized_keys options are skipped.
+func parseSSHPublicKey(line string) (SSHKey, []byte, error) {
+	fields := strings.Fields(line)
+	err := fmt.Errorf("no public key found")
+	// Quoted options may hold spaces and even a key type, as in
+	// command="echo ssh-rsa", so every candidate is tried
+	for i := 0; i+1 < len(fields); i++ {
+		if !sshKeyTypes[fields[i]] && !strings.HasSuffix(fields[i], "-cert-v01@openssh.com") {
+			continue
+		}
+		blob, decodeErr := base64.StdEncoding.DecodeString(fields[i+1])
+		if decodeErr != nil {
+			err = fmt.Errorf("invalid public key encoding: %v", decodeErr)
+			continue
+		}
+		// The blob starts with the length-prefixed key type
+		if len(blob) < 4 || uint64(binary.BigEndian.Uint32(blob)) > uint64(len(blob)-4) ||
+			string(blob[4:4+binary.BigEndian.Uint32(blob)]) != fields[i] {
+			err = fmt.Errorf("public key type does not match %s", fields[i])
+			continue
+		}
+		return SSHKey{
+			Type:        fields[i],
+			Fingerprint: sshFingerprint(blob),
+			Comment:     strings.Join(fields[i+2:], " "),
+		}, blob, nil
+	}
+	return SSHKey{}, nil, err
+}
+
+// sshFingerprint formats the SHA256 fingerprint of a public key blob
+func sshFingerprint(blob []byte) string {
+	sum := sha256.Sum256(blob)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
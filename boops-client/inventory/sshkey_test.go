@@ -0,0 +1,96 @@
+package inventory
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Keys made with ssh-keygen; the fingerprints are what ssh-keygen -l prints
+const (
+	testEd25519Key = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAICq/MiR3erX27fAg2ZQfOJJFpXcS+dsXD2irlmPQ3tJ0"
+	testEd25519FP  = "SHA256:8eb8bEwuVtbr7T94L8kw0SBLC4c9XOpXzrguVQEIkeU"
+	testRSAKey     = "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAAAgQC72S3Zx8AAJRDV8DpDCAr5YQ5yEWnFjNgMv1bnyNDXnSPIXQlY1oehYghfF44mI+5VJfS8M479ZlMjCFGKakrwMYqfNT6tKn3mGfxumJXfA5NcynftYu+FUyWwl71QliL/7u8GMiFSYk0SuQbBjcedxEjVgD1uDLpGlMtZi213vw=="
+	testRSAFP      = "SHA256:iCXxYOrIs4FC9vXg9aPR4AYTgSgtJfAT14GtqDf3GpI"
+	testECDSAKey   = "ecdsa-sha2-nistp256 AAAAE2VjZHNhLXNoYTItbmlzdHAyNTYAAAAIbmlzdHAyNTYAAABBBJx3VJmH25IhdAOMwgBPGMSXnpwJGb5QsrUIlwp1mUwyfpRpCjbnIWTYs1plYLSd7LO4yV3NRecfejCDHEtKTRU="
+	testECDSAFP    = "SHA256:JImEOxyssHwuWM+tHpBjDQO6ZNlX0317iaSVwKgumZA"
+)
+
+func TestParseSSHPublicKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    SSHKey
+		wantErr bool
+	}{
+		{
+			name: "ed25519 with comment",
+			line: testEd25519Key + " alice@laptop",
+			want: SSHKey{Type: "ssh-ed25519", Fingerprint: testEd25519FP, Comment: "alice@laptop"},
+		},
+		{
+			name: "rsa without comment",
+			line: testRSAKey,
+			want: SSHKey{Type: "ssh-rsa", Fingerprint: testRSAFP},
+		},
+		{
+			name: "comment with spaces",
+			line: testECDSAKey + " deploy key",
+			want: SSHKey{Type: "ecdsa-sha2-nistp256", Fingerprint: testECDSAFP, Comment: "deploy key"},
+		},
+		{
+			name: "authorized_keys options",
+			line: `no-pty,from="10.0.0.0/8" ` + testEd25519Key + " backup",
+			want: SSHKey{Type: "ssh-ed25519", Fingerprint: testEd25519FP, Comment: "backup"},
+		},
+		{
+			name: "quoted option holding a key type",
+			line: `command="echo ssh-rsa is not a key" ` + testRSAKey + " ci",
+			want: SSHKey{Type: "ssh-rsa", Fingerprint: testRSAFP, Comment: "ci"},
+		},
+		{
+			name:    "type does not match the blob",
+			line:    "ssh-rsa " + testEd25519Key[len("ssh-ed25519 "):],
+			wantErr: true,
+		},
+		{
+			name:    "invalid base64",
+			line:    "ssh-ed25519 not-base64!",
+			wantErr: true,
+		},
+		{
+			name:    "truncated blob",
+			line:    "ssh-ed25519 AAAA",
+			wantErr: true,
+		},
+		{
+			name:    "length beyond the blob",
+			line:    "ssh-ed25519 /////wAA",
+			wantErr: true,
+		},
+		{
+			name:    "unknown type",
+			line:    "ssh-foo AAAAB3NzaC1yc2E=",
+			wantErr: true,
+		},
+		{
+			name:    "type only",
+			line:    "ssh-ed25519",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, blob, err := parseSSHPublicKey(tt.line)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSSHPublicKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSSHPublicKey() = %+v, want %+v", got, tt.want)
+			}
+			if !tt.wantErr && sshFingerprint(blob) != tt.want.Fingerprint {
+				t.Errorf("parseSSHPublicKey() blob fingerprint = %s, want %s", sshFingerprint(blob), tt.want.Fingerprint)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package inventory
+
+import "syscall"
+
+// statFilesystem fills in the space and inode usage of the filesystem
+// mounted at path
+func statFilesystem(path string, fs *Filesystem) error {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return err
+	}
+	size := uint64(st.Frsize)
+	if size == 0 {
+		size = uint64(st.Bsize)
+	}
+	fs.Total = st.Blocks * size
+	fs.Used = (st.Blocks - st.Bfree) * size
+	fs.Free = st.Bavail * size
+	fs.Inodes = st.Files
+	fs.InodesFree = st.Ffree
+	fs.InodesUsed = st.Files - st.Ffree
+	return nil
+}
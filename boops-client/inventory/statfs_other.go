@@ -0,0 +1,10 @@
+//go:build !linux
+
+package inventory
+
+import "fmt"
+
+// statFilesystem is only implemented on Linux
+func statFilesystem(path string, fs *Filesystem) error {
+	return fmt.Errorf("statfs: %w", ErrNotAvailable)
+}
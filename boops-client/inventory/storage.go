@@ -0,0 +1,132 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// Storage node kinds
+const (
+	StorageMD    = "md"
+	StorageLV    = "lv"
+	StoragePV    = "pv"
+	StorageZpool = "zpool"
+	StorageVdev  = "vdev"
+	StorageGroup = "group"
+	StorageDisk  = "disk"
+)
+
+// StorageNode is one layer of the storage stack. Children are the devices
+// the node is built from, so walking down from a logical volume ends at the
+// physical disks.
+type StorageNode struct {
+	Name     string        `json:"name"`
+	Kind     string        `json:"kind"`
+	Level    string        `json:"level,omitempty"`
+	Group    string        `json:"group,omitempty"`
+	Size     uint64        `json:"size,omitempty"`
+	State    string        `json:"state,omitempty"`
+	Degraded bool          `json:"degraded,omitempty"`
+	Children []StorageNode `json:"children,omitempty"`
+}
+
+// Storage is the md RAID, LVM and ZFS topology of the host
+type Storage struct {
+	Tree []StorageNode `json:"tree"`
+	// Degraded lists the names of every degraded node
+	Degraded []string `json:"degraded,omitempty"`
+}
+
+// StorageStack builds the storage tree from md, LVM and ZFS. Sources that
+// are not in use are skipped; ErrNotAvailable is only returned when none of
+// them exist.
+func StorageStack(ctx context.Context, root Root) (*Storage, error) {
+	arrays, mdErr := MDArrays(root)
+	lvs, lvmErr := LogicalVolumes(ctx)
+	pools, zfsErr := ZpoolStatus(ctx)
+
+	var errs []error
+	available := false
+	for _, err := range []error{mdErr, lvmErr, zfsErr} {
+		switch {
+		case err == nil:
+			available = true
+		case !isNotAvailable(err):
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	if !available {
+		return nil, fmt.Errorf("no md, LVM or ZFS storage: %w", ErrNotAvailable)
+	}
+	return storageTree(arrays, lvs, pools), nil
+}
+
+// storageTree joins the md arrays, logical volumes and pools into one tree
+// and collects the degraded nodes
+func storageTree(arrays, lvs, pools []StorageNode) *Storage {
+	// md arrays used as physical volumes are shown under their LVs only
+	byName := make(map[string]StorageNode, len(arrays))
+	for _, md := range arrays {
+		byName["/dev/"+md.Name] = md
+	}
+	used := make(map[string]bool)
+	storage := &Storage{}
+	for _, lv := range lvs {
+		attachArrays(&lv, byName, used)
+		storage.Tree = append(storage.Tree, lv)
+	}
+	for _, md := range arrays {
+		if !used["/dev/"+md.Name] {
+			storage.Tree = append(storage.Tree, md)
+		}
+	}
+	storage.Tree = append(storage.Tree, pools...)
+
+	for i := range storage.Tree {
+		propagateDegraded(&storage.Tree[i], &storage.Degraded)
+	}
+	return storage
+}
+
+// attachArrays places each md array below the physical volume on it
+func attachArrays(node *StorageNode, arrays map[string]StorageNode, used map[string]bool) {
+	if md, ok := arrays[node.Name]; ok && node.Kind == StoragePV {
+		node.Children = []StorageNode{md}
+		used[node.Name] = true
+		return
+	}
+	for i := range node.Children {
+		attachArrays(&node.Children[i], arrays, used)
+	}
+}
+
+// propagateDegraded marks every node above a degraded one as degraded too,
+// so a logical volume on a broken mirror is flagged, and collects the names
+// of the nodes that are degraded themselves
+func propagateDegraded(node *StorageNode, names *[]string) bool {
+	if node.Degraded {
+		*names = append(*names, node.Name)
+	}
+	for i := range node.Children {
+		if propagateDegraded(&node.Children[i], names) {
+			node.Degraded = true
+		}
+	}
+	return node.Degraded
+}
+
+func isNotAvailable(err error) bool {
+	return errors.Is(err, ErrNotAvailable) || errors.Is(err, fs.ErrNotExist)
+}
+
+// NewStorageCollector reports the md RAID, LVM and ZFS topology
+func NewStorageCollector(root Root) Collector {
+	return NewCollector("storage", linuxOnly, func(ctx context.Context) (interface{}, error) {
+		return StorageStack(ctx, root)
+	})
+}
@@ -0,0 +1,72 @@
+package inventory
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStorageTree(t *testing.T) {
+	arrays := []StorageNode{
+		{Name: "md0", Kind: StorageMD, Level: "raid1", State: "clean", Children: []StorageNode{
+			{Name: "sda1", Kind: StorageDisk, State: "in_sync"},
+			{Name: "sdb1", Kind: StorageDisk, State: "faulty", Degraded: true},
+		}},
+		{Name: "md1", Kind: StorageMD, Level: "raid1", State: "clean", Children: []StorageNode{
+			{Name: "sdc1", Kind: StorageDisk, State: "in_sync"},
+		}},
+	}
+	lvs := []StorageNode{
+		{Name: "vg0/data", Kind: StorageLV, Group: "vg0", Children: []StorageNode{
+			{Name: "/dev/md0", Kind: StoragePV, Group: "vg0"},
+		}},
+		{Name: "vg0/home", Kind: StorageLV, Group: "vg0", Children: []StorageNode{
+			{Name: "/dev/sdd1", Kind: StoragePV, Group: "vg0"},
+		}},
+	}
+	pools := []StorageNode{
+		{Name: "tank", Kind: StorageZpool, State: "ONLINE", Children: []StorageNode{
+			{Name: "mirror-0", Kind: StorageVdev, State: "ONLINE", Children: []StorageNode{
+				{Name: "sde", Kind: StorageDisk, State: "UNAVAIL", Degraded: true},
+			}},
+		}},
+	}
+
+	got := storageTree(arrays, lvs, pools)
+
+	var names []string
+	for _, node := range got.Tree {
+		names = append(names, node.Name)
+	}
+	// md0 sits below the PV it backs instead of at the top
+	if want := []string{"vg0/data", "vg0/home", "md1", "tank"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("storageTree() top level = %v, want %v", names, want)
+	}
+	if want := []string{"sdb1", "sde"}; !reflect.DeepEqual(got.Degraded, want) {
+		t.Errorf("storageTree() Degraded = %v, want %v", got.Degraded, want)
+	}
+
+	data := got.Tree[0]
+	pv := data.Children[0]
+	if len(pv.Children) != 1 || pv.Children[0].Name != "md0" {
+		t.Fatalf("storageTree() /dev/md0 children = %+v, want md0", pv.Children)
+	}
+	// A failed disk marks the array, the PV and the LV above it
+	for _, node := range []StorageNode{data, pv, pv.Children[0]} {
+		if !node.Degraded {
+			t.Errorf("storageTree() %s not degraded", node.Name)
+		}
+	}
+	for _, node := range []StorageNode{got.Tree[1], got.Tree[2]} {
+		if node.Degraded {
+			t.Errorf("storageTree() %s degraded", node.Name)
+		}
+	}
+	tank := got.Tree[3]
+	if !tank.Degraded || !tank.Children[0].Degraded {
+		t.Errorf("storageTree() tank = %+v, want it and mirror-0 degraded", tank)
+	}
+	// The input arrays are not modified
+	if arrays[0].Degraded {
+		t.Error("storageTree() modified its input")
+	}
+}
@@ -0,0 +1,235 @@
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Service is one systemd service. Active and Sub are empty when systemd
+// isn't running, e.g. in a container or when reading an offline root.
+type Service struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Load        string `json:"load,omitempty"`
+	Active      string `json:"active,omitempty"`
+	Sub         string `json:"sub,omitempty"`
+	// Enabled is the unit file state: enabled, disabled, static, masked...
+	Enabled string `json:"enabled,omitempty"`
+}
+
+// Services is the service list plus a summary of what stands out
+type Services struct {
+	Services []Service `json:"services"`
+	// Running lists services that are active and running
+	Running []string `json:"running"`
+	// EnabledDead lists services enabled at boot that aren't running
+	EnabledDead []string `json:"enabled_dead"`
+	// Failed lists failed units of any type, not just services
+	Failed []string `json:"failed"`
+}
+
+// systemdUnitDirs are the unit file directories in order of precedence
+var systemdUnitDirs = []string{"etc/systemd/system", "run/systemd/system", "usr/lib/systemd/system", "lib/systemd/system"}
+
+// systemdUnit is an entry of "systemctl list-units --output=json"
+type systemdUnit struct {
+	Unit        string `json:"unit"`
+	Load        string `json:"load"`
+	Active      string `json:"active"`
+	Sub         string `json:"sub"`
+	Description string `json:"description"`
+}
+
+// systemdUnitFile is an entry of "systemctl list-unit-files --output=json"
+type systemdUnitFile struct {
+	UnitFile string `json:"unit_file"`
+	State    string `json:"state"`
+}
+
+// SystemdServices lists the services with their runtime and unit file
+// state. On the live root systemctl is asked; otherwise, or when systemctl
+// is missing, the unit file directories are read and only the unit file
+// state is known.
+func SystemdServices(ctx context.Context, root Root) (*Services, error) {
+	byName := make(map[string]*Service)
+	service := func(name string) *Service {
+		if byName[name] == nil {
+			byName[name] = &Service{Name: name}
+		}
+		return byName[name]
+	}
+
+	var failed []string
+	enabled, err := systemctlUnitFiles(ctx, root)
+	if err != nil {
+		if enabled, err = unitFileStates(root); err != nil {
+			return nil, err
+		}
+	}
+	for name, state := range enabled {
+		service(name).Enabled = state
+	}
+
+	// Runtime state needs a running systemd to talk to; /run/systemd/system
+	// exists when it was booted with systemd (sd_booted)
+	if root.live() && root.Exists("run", "systemd", "system") {
+		units, err := systemctlUnits(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range units {
+			if u.Active == "failed" {
+				failed = append(failed, u.Unit)
+			}
+			if !strings.HasSuffix(u.Unit, ".service") {
+				continue
+			}
+			s := service(u.Unit)
+			s.Description, s.Load, s.Active, s.Sub = u.Description, u.Load, u.Active, u.Sub
+		}
+	}
+
+	result := &Services{Services: []Service{}, Running: []string{}, EnabledDead: []string{}, Failed: []string{}}
+	for _, s := range byName {
+		result.Services = append(result.Services, *s)
+		switch {
+		case s.Active == "active" && s.Sub == "running":
+			result.Running = append(result.Running, s.Name)
+		case s.Enabled == "enabled" && s.Active == "inactive":
+			result.EnabledDead = append(result.EnabledDead, s.Name)
+		}
+	}
+	result.Failed = append(result.Failed, failed...)
+	sort.Slice(result.Services, func(i, j int) bool { return result.Services[i].Name < result.Services[j].Name })
+	sort.Strings(result.Running)
+	sort.Strings(result.EnabledDead)
+	sort.Strings(result.Failed)
+	return result, nil
+}
+
+// systemctlUnits lists every loaded unit with its runtime state. systemd
+// before 246 (RHEL 8, Ubuntu 20.04) has no JSON output, so the plain table
+// is parsed there.
+func systemctlUnits(ctx context.Context) ([]systemdUnit, error) {
+	output, err := runCommand(ctx, "systemctl", "list-units", "--all", "--output=json", "--no-pager")
+	if err == nil {
+		var units []systemdUnit
+		if err := json.Unmarshal(output, &units); err != nil {
+			return nil, fmt.Errorf("failed to parse systemctl list-units output: %v", err)
+		}
+		return units, nil
+	}
+	if isNotAvailable(err) {
+		return nil, err
+	}
+
+	output, err = runCommand(ctx, "systemctl", "list-units", "--all", "--plain", "--no-legend", "--no-pager")
+	if err != nil {
+		return nil, err
+	}
+	return parseSystemctlUnits(output), nil
+}
+
+// parseSystemctlUnits parses "systemctl list-units --plain --no-legend":
+// unit, load, active and sub columns followed by the description
+func parseSystemctlUnits(output []byte) []systemdUnit {
+	var units []systemdUnit
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		// Failed units are marked with a bullet even in plain mode on some
+		// versions
+		if len(fields) > 0 && (fields[0] == "●" || fields[0] == "*") {
+			fields = fields[1:]
+		}
+		if len(fields) < 4 {
+			continue
+		}
+		units = append(units, systemdUnit{
+			Unit:        fields[0],
+			Load:        fields[1],
+			Active:      fields[2],
+			Sub:         fields[3],
+			Description: strings.Join(fields[4:], " "),
+		})
+	}
+	return units
+}
+
+// systemctlUnitFiles returns the unit file state of every service. It works
+// without a running systemd, so it is tried in containers too.
+func systemctlUnitFiles(ctx context.Context, root Root) (map[string]string, error) {
+	if !root.live() {
+		return nil, fmt.Errorf("systemctl: %w", ErrNotAvailable)
+	}
+	output, err := runCommand(ctx, "systemctl", "list-unit-files", "--type=service", "--output=json", "--no-pager")
+	if err != nil {
+		return nil, err
+	}
+	var files []systemdUnitFile
+	if err := json.Unmarshal(output, &files); err != nil {
+		return nil, fmt.Errorf("failed to parse systemctl list-unit-files output: %v", err)
+	}
+	states := make(map[string]string)
+	for _, f := range files {
+		// Templates only exist through their instances
+		if !strings.HasSuffix(f.UnitFile, "@.service") {
+			states[f.UnitFile] = f.State
+		}
+	}
+	return states, nil
+}
+
+// unitFileStates works out the unit file state from the directories: a
+// unit is enabled when linked from a .wants or .requires directory under
+// /etc, masked when its file points at /dev/null, disabled when it has an
+// [Install] section and static otherwise
+func unitFileStates(root Root) (map[string]string, error) {
+	states := make(map[string]string)
+	found := false
+	for _, dir := range systemdUnitDirs {
+		entries, err := os.ReadDir(root.Path(dir))
+		if err != nil {
+			continue
+		}
+		found = true
+		for _, e := range entries {
+			name := e.Name()
+			if !strings.HasSuffix(name, ".service") || strings.HasSuffix(name, "@.service") || states[name] != "" {
+				continue
+			}
+			path := filepath.Join(root.Path(dir), name)
+			if target, err := os.Readlink(path); err == nil && target == "/dev/null" {
+				states[name] = "masked"
+				continue
+			}
+			states[name] = "static"
+			if data, err := os.ReadFile(path); err == nil && strings.Contains(string(data), "[Install]") {
+				states[name] = "disabled"
+			}
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("systemd unit files: %w", ErrNotAvailable)
+	}
+
+	wants, _ := filepath.Glob(filepath.Join(root.Path("etc/systemd/system"), "*.wants", "*.service"))
+	requires, _ := filepath.Glob(filepath.Join(root.Path("etc/systemd/system"), "*.requires", "*.service"))
+	for _, link := range append(wants, requires...) {
+		if name := filepath.Base(link); states[name] != "masked" {
+			states[name] = "enabled"
+		}
+	}
+	return states, nil
+}
+
+// NewSystemdCollector reports the systemd services and failed units
+func NewSystemdCollector(root Root) Collector {
+	return NewCollector("services", linuxOnly, func(ctx context.Context) (interface{}, error) {
+		return SystemdServices(ctx, root)
+	})
+}
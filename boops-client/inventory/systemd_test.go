@@ -0,0 +1,101 @@
+package inventory
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestParseSystemctlUnits(t *testing.T) {
+	output := "  proc-sys-fs-binfmt_misc.automount loaded active waiting Arbitrary Executable File Formats File System Automount Point\n" +
+		"● nginx.service loaded failed failed A high performance web server\n" +
+		"* postfix.service loaded failed failed Postfix Mail Transport Agent\n" +
+		"ssh.service loaded active running OpenBSD Secure Shell server\n" +
+		"tmp.mount loaded inactive dead\n" +
+		"\n" +
+		"not enough\n"
+	want := []systemdUnit{
+		{Unit: "proc-sys-fs-binfmt_misc.automount", Load: "loaded", Active: "active", Sub: "waiting", Description: "Arbitrary Executable File Formats File System Automount Point"},
+		{Unit: "nginx.service", Load: "loaded", Active: "failed", Sub: "failed", Description: "A high performance web server"},
+		{Unit: "postfix.service", Load: "loaded", Active: "failed", Sub: "failed", Description: "Postfix Mail Transport Agent"},
+		{Unit: "ssh.service", Load: "loaded", Active: "active", Sub: "running", Description: "OpenBSD Secure Shell server"},
+		{Unit: "tmp.mount", Load: "loaded", Active: "inactive", Sub: "dead"},
+	}
+	if got := parseSystemctlUnits([]byte(output)); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseSystemctlUnits() = %+v, want %+v", got, want)
+	}
+}
+
+const unitWithInstall = "[Service]\nExecStart=/bin/true\n\n[Install]\nWantedBy=multi-user.target\n"
+
+// unitFixture is a root with unit files in /lib and /etc, links in /etc
+// .wants and .requires directories and masked units
+func unitFixture(t *testing.T) Root {
+	t.Helper()
+	root := fixtureRoot(t, map[string]string{
+		"lib/systemd/system/ssh.service":           unitWithInstall,
+		"lib/systemd/system/cron.service":          unitWithInstall,
+		"lib/systemd/system/systemd-udevd.service": "[Service]\nExecStart=/lib/systemd/systemd-udevd\n",
+		"lib/systemd/system/getty@.service":        unitWithInstall,
+		"lib/systemd/system/rsync.service":         unitWithInstall,
+		"lib/systemd/system/apache2.service":       unitWithInstall,
+		"lib/systemd/system/multi-user.target":     "[Unit]\n",
+		// A unit file in /etc overrides the packaged one
+		"etc/systemd/system/cron.service":       "[Service]\nExecStart=/usr/sbin/cron -f\n",
+		"usr/lib/systemd/system/docker.service": unitWithInstall,
+	})
+	links := map[string]string{
+		"etc/systemd/system/rsync.service":                                 "/dev/null",
+		"etc/systemd/system/multi-user.target.wants/ssh.service":           "/lib/systemd/system/ssh.service",
+		"etc/systemd/system/multi-user.target.wants/rsync.service":         "/lib/systemd/system/rsync.service",
+		"etc/systemd/system/getty.target.wants/getty@tty1.service":         "/lib/systemd/system/getty@.service",
+		"etc/systemd/system/network-online.target.requires/docker.service": "/usr/lib/systemd/system/docker.service",
+	}
+	for link, target := range links {
+		if err := os.MkdirAll(root.Path(link, ".."), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(target, root.Path(link)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestUnitFileStates(t *testing.T) {
+	want := map[string]string{
+		"ssh.service":           "enabled",
+		"cron.service":          "static",
+		"systemd-udevd.service": "static",
+		"rsync.service":         "masked",
+		"apache2.service":       "disabled",
+		"docker.service":        "enabled",
+		"getty@tty1.service":    "enabled",
+	}
+	got, err := unitFileStates(unitFixture(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unitFileStates() = %v, want %v", got, want)
+	}
+
+	if _, err := unitFileStates(fixtureRoot(t, nil)); !isNotAvailable(err) {
+		t.Errorf("unitFileStates() error = %v, want not available", err)
+	}
+}
+
+func TestSystemdServicesOffline(t *testing.T) {
+	got, err := SystemdServices(context.Background(), unitFixture(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Services) != 7 || got.Services[0].Name != "apache2.service" || got.Services[0].Enabled != "disabled" {
+		t.Errorf("SystemdServices() services = %+v, want 7 sorted by name", got.Services)
+	}
+	// Without a running systemd nothing is known to be running or dead
+	if len(got.Running) != 0 || len(got.EnabledDead) != 0 || len(got.Failed) != 0 {
+		t.Errorf("SystemdServices() = %+v, want no runtime state", got)
+	}
+}
@@ -0,0 +1,120 @@
+package inventory
+
+import (
+	"context"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// USBDevice is one device on the USB bus
+type USBDevice struct {
+	// Path is the bus-port path, e.g. "1-1.2" for bus 1, port 1, hub port 2
+	Path         string `json:"path"`
+	Bus          int    `json:"bus"`
+	Device       int    `json:"device"`
+	VendorID     string `json:"vendor_id"`
+	ProductID    string `json:"product_id"`
+	Manufacturer string `json:"manufacturer,omitempty"`
+	Product      string `json:"product,omitempty"`
+	Serial       string `json:"serial,omitempty"`
+	// SpeedMbps is the negotiated speed, e.g. 12, 480 or 5000
+	SpeedMbps string `json:"speed_mbps,omitempty"`
+}
+
+// key identifies a device across runs: by serial when it has one, so a
+// dongle moved to another port isn't reported as detached and attached
+func (d USBDevice) key() string {
+	if d.Serial != "" {
+		return d.VendorID + ":" + d.ProductID + ":" + d.Serial
+	}
+	return d.VendorID + ":" + d.ProductID + "@" + d.Path
+}
+
+// USBInventory is the USB device list plus what changed since the last run
+type USBInventory struct {
+	Devices  []USBDevice `json:"devices"`
+	Attached []USBDevice `json:"attached,omitempty"`
+	Detached []USBDevice `json:"detached,omitempty"`
+}
+
+// USBDevices walks /sys/bus/usb/devices, skipping root hubs and interfaces
+func USBDevices(root Root) ([]USBDevice, error) {
+	entries, err := os.ReadDir(root.Path("sys", "bus", "usb", "devices"))
+	if err != nil {
+		return nil, err
+	}
+
+	var devices []USBDevice
+	for _, entry := range entries {
+		name := entry.Name()
+		// "usb1" is a root hub, "1-1:1.0" an interface of device 1-1
+		if strings.HasPrefix(name, "usb") || strings.Contains(name, ":") {
+			continue
+		}
+		attr := func(a string) string {
+			v, _ := root.ReadString("sys", "bus", "usb", "devices", name, a)
+			return v
+		}
+		dev := USBDevice{
+			Path:         name,
+			VendorID:     attr("idVendor"),
+			ProductID:    attr("idProduct"),
+			Manufacturer: attr("manufacturer"),
+			Product:      attr("product"),
+			Serial:       attr("serial"),
+			SpeedMbps:    attr("speed"),
+		}
+		dev.Bus, _ = strconv.Atoi(attr("busnum"))
+		dev.Device, _ = strconv.Atoi(attr("devnum"))
+		devices = append(devices, dev)
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].Path < devices[j].Path })
+	return devices, nil
+}
+
+// USB collects the USB devices and compares them with the snapshot of the
+// last uploaded run. The first run reports no changes.
+func USB(root Root) (*USBInventory, error) {
+	devices, err := USBDevices(root)
+	if err != nil {
+		return nil, err
+	}
+	inv := &USBInventory{Devices: devices}
+
+	var previous []USBDevice
+	if loadSnapshot("usb", &previous) {
+		byKey := make(map[string]USBDevice)
+		var prevKeys, curKeys []string
+		for _, d := range previous {
+			byKey[d.key()] = d
+			prevKeys = append(prevKeys, d.key())
+		}
+		for _, d := range devices {
+			byKey[d.key()] = d
+			curKeys = append(curKeys, d.key())
+		}
+		added, removed := changedKeys(prevKeys, curKeys)
+		for _, k := range added {
+			inv.Attached = append(inv.Attached, byKey[k])
+		}
+		for _, k := range removed {
+			inv.Detached = append(inv.Detached, byKey[k])
+		}
+	}
+	return inv, nil
+}
+
+// Commit records the devices as the baseline for the next run. Call it once
+// the inventory has been uploaded, so changes aren't lost to a failed upload.
+func (inv *USBInventory) Commit() error {
+	return saveSnapshot("usb", inv.Devices)
+}
+
+// NewUSBCollector reports USB devices and attach/detach changes
+func NewUSBCollector(root Root) Collector {
+	return NewCollector("usb", linuxOnly, func(ctx context.Context) (interface{}, error) {
+		return USB(root)
+	})
+}
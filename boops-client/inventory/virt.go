@@ -0,0 +1,56 @@
+package inventory
+
+import (
+	"context"
+	"strings"
+)
+
+// virtualProducts are DMI product/vendor substrings used by common hypervisors
+var virtualProducts = []string{
+	"kvm", "qemu", "vmware", "virtualbox", "virtual machine", "hvm domu",
+	"bochs", "parallels", "bhyve", "openstack", "google compute engine",
+}
+
+// IsVirtual reports whether the host is a virtual machine or container,
+// using the same signals as systemd-detect-virt without requiring systemd
+func IsVirtual(root Root) bool {
+	// Containers
+	if root.Exists(".dockerenv") || root.Exists("run", ".containerenv") {
+		return true
+	}
+	if container, err := root.ReadString("run", "systemd", "container"); err == nil && container != "" {
+		return true
+	}
+
+	// Hypervisors announce themselves through CPUID and sysfs
+	if cpuinfo, err := root.readKeyValues(":", "proc", "cpuinfo"); err == nil {
+		for _, flag := range strings.Fields(cpuinfo["flags"]) {
+			if flag == "hypervisor" {
+				return true
+			}
+		}
+	}
+	if hv, err := root.ReadString("sys", "hypervisor", "type"); err == nil && hv != "" {
+		return true
+	}
+	for _, attr := range []string{"sys_vendor", "product_name", "bios_vendor"} {
+		v, err := root.ReadString("sys", "class", "dmi", "id", attr)
+		if err != nil {
+			continue
+		}
+		v = strings.ToLower(v)
+		for _, product := range virtualProducts {
+			if strings.Contains(v, product) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NewVirtualizationCollector reports whether the host is virtualized
+func NewVirtualizationCollector(root Root) Collector {
+	return NewCollector("virtual", linuxOnly, func(ctx context.Context) (interface{}, error) {
+		return IsVirtual(root), nil
+	})
+}
@@ -0,0 +1,96 @@
+package inventory
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"regexp"
+	"strings"
+)
+
+// zfsGroups are the pseudo-vdevs zpool status lists special devices under
+var zfsGroups = map[string]bool{"logs": true, "cache": true, "spares": true, "special": true, "dedup": true}
+
+// zfsVdevPattern matches redundancy vdevs such as mirror-0, raidz2-1 or
+// draid1:4d:8c:1s-0
+var zfsVdevPattern = regexp.MustCompile(`^(mirror|raidz[123]?|draid[123]?[^-]*|replacing|spare)-\d+$`)
+
+// ZpoolStatus parses "zpool status" into one tree per pool
+func ZpoolStatus(ctx context.Context) ([]StorageNode, error) {
+	output, err := runCommand(ctx, "zpool", "status")
+	if err != nil {
+		return nil, err
+	}
+	return parseZpoolStatus(output), nil
+}
+
+func parseZpoolStatus(output []byte) []StorageNode {
+	type frame struct {
+		indent int
+		node   *StorageNode
+	}
+	var pools []*StorageNode
+	var stack []frame
+	inConfig := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "pool:"):
+			inConfig, stack = false, nil
+			continue
+		case strings.HasPrefix(trimmed, "config:"):
+			inConfig = true
+			continue
+		case strings.HasPrefix(trimmed, "errors:"):
+			inConfig = false
+			continue
+		}
+		fields := strings.Fields(trimmed)
+		if !inConfig || len(fields) == 0 || fields[0] == "NAME" {
+			continue
+		}
+
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		indent += strings.Count(line[:indent], "\t") * 7 // A tab counts as 8 columns
+		node := &StorageNode{Name: fields[0], Kind: StorageDisk}
+		if len(fields) > 1 {
+			node.State = fields[1]
+			node.Degraded = node.State != "ONLINE" && node.State != "AVAIL" && node.State != "INUSE"
+		}
+		switch {
+		case zfsGroups[node.Name] && len(fields) == 1 && len(pools) > 0:
+			// Groups are printed at the pool's indentation but belong to it
+			node.Kind = StorageGroup
+			stack = []frame{{indent - 1, pools[len(pools)-1]}}
+		case len(stack) == 0:
+			node.Kind = StorageZpool
+		case zfsVdevPattern.MatchString(node.Name):
+			node.Kind = StorageVdev
+			node.Level = node.Name[:strings.LastIndex(node.Name, "-")]
+		}
+
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		// node points into its parent's Children from here on. Appending a
+		// sibling may move that slice, but the previous sibling has been
+		// popped off the stack by then, so no stale pointer is ever used.
+		if len(stack) == 0 {
+			pools = append(pools, node)
+		} else {
+			parent := stack[len(stack)-1].node
+			parent.Children = append(parent.Children, *node)
+			node = &parent.Children[len(parent.Children)-1]
+		}
+		stack = append(stack, frame{indent, node})
+	}
+
+	result := make([]StorageNode, 0, len(pools))
+	for _, pool := range pools {
+		result = append(result, *pool)
+	}
+	return result
+}
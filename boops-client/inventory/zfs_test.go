@@ -0,0 +1,82 @@
+package inventory
+
+import (
+	"reflect"
+	"testing"
+)
+
+const zpoolStatusFixture = `  pool: tank
+ state: DEGRADED
+status: One or more devices are faulted in response to persistent errors.
+config:
+
+	NAME        STATE     READ WRITE CKSUM
+	tank        DEGRADED     0     0     0
+	  mirror-0  DEGRADED     0     0     0
+	    sda     ONLINE       0     0     0
+	    sdb     FAULTED      3     0     0  too many errors
+	  raidz2-1  ONLINE       0     0     0
+	    sdc     ONLINE       0     0     0
+	    sdd     ONLINE       0     0     0
+	    sde     ONLINE       0     0     0
+	    sdf     ONLINE       0     0     0
+	    sdg     ONLINE       0     0     0
+	logs
+	  nvme0n1   ONLINE       0     0     0
+	cache
+	  nvme1n1   ONLINE       0     0     0
+	spares
+	  sdh       AVAIL
+	  sdi       INUSE     currently in use
+
+errors: No known data errors
+
+  pool: rpool
+ state: ONLINE
+config:
+
+	NAME         STATE     READ WRITE CKSUM
+	rpool        ONLINE       0     0     0
+	  nvme2n1p3  ONLINE       0     0     0
+
+errors: No known data errors
+`
+
+func TestParseZpoolStatus(t *testing.T) {
+	disk := func(name, state string) StorageNode {
+		return StorageNode{Name: name, Kind: StorageDisk, State: state, Degraded: state == "FAULTED"}
+	}
+	want := []StorageNode{
+		{Name: "tank", Kind: StorageZpool, State: "DEGRADED", Degraded: true, Children: []StorageNode{
+			{Name: "mirror-0", Kind: StorageVdev, Level: "mirror", State: "DEGRADED", Degraded: true, Children: []StorageNode{
+				disk("sda", "ONLINE"),
+				disk("sdb", "FAULTED"),
+			}},
+			// Enough children to move the slices the parser holds pointers into
+			{Name: "raidz2-1", Kind: StorageVdev, Level: "raidz2", State: "ONLINE", Children: []StorageNode{
+				disk("sdc", "ONLINE"),
+				disk("sdd", "ONLINE"),
+				disk("sde", "ONLINE"),
+				disk("sdf", "ONLINE"),
+				disk("sdg", "ONLINE"),
+			}},
+			{Name: "logs", Kind: StorageGroup, Children: []StorageNode{disk("nvme0n1", "ONLINE")}},
+			{Name: "cache", Kind: StorageGroup, Children: []StorageNode{disk("nvme1n1", "ONLINE")}},
+			{Name: "spares", Kind: StorageGroup, Children: []StorageNode{disk("sdh", "AVAIL"), disk("sdi", "INUSE")}},
+		}},
+		{Name: "rpool", Kind: StorageZpool, State: "ONLINE", Children: []StorageNode{
+			disk("nvme2n1p3", "ONLINE"),
+		}},
+	}
+
+	got := parseZpoolStatus([]byte(zpoolStatusFixture))
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseZpoolStatus() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseZpoolStatusEmpty(t *testing.T) {
+	if got := parseZpoolStatus([]byte("no pools available\n")); len(got) != 0 {
+		t.Errorf("parseZpoolStatus() = %+v, want none", got)
+	}
+}
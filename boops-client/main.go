@@ -1,27 +1,29 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
-	"os/exec"
 	"runtime"
 	"strings"
 
 	"boops/client"
+	"boops/diff"
+	"boops/drift"
+	"boops/fleet"
+	"boops/inventory"
 	"boops/system"
+	"boops/validate"
 )
 
 var apiBase = "https://boopsdb-api.booyah.dev/api/machines"
 
 // var apiBase = "http://10.0.1.1:3001/api/machines"
 
-// Store current network settings
-var currentSettings map[string]client.InterfaceInfo
-
 // ANSI color codes
 const (
 	Reset       = "\033[0m"
@@ -63,7 +65,7 @@ func PrintStyledMessage(msgType string, msg string) {
 
 func main() {
 	if len(os.Args) < 2 {
-		log.Fatal("Usage: boops <regist|sync> [machine-id]")
+		log.Fatal("Usage: boops <regist|sync|plan|fleet> [machine-id]")
 	}
 
 	switch os.Args[1] {
@@ -77,14 +79,34 @@ func main() {
 		if err != nil {
 			log.Fatal("Not registered. Run: boops regist <machine-id>")
 		}
-		handleSync(cfg.ID)
+		handleSync(cfg)
+	case "plan":
+		cfg, err := client.LoadConfig()
+		if err != nil {
+			log.Fatal("Not registered. Run: boops regist <machine-id>")
+		}
+		handlePlan(cfg)
+	case "fleet":
+		if len(os.Args) < 3 || len(os.Args) > 4 || os.Args[2] != "known-hosts" {
+			log.Fatal("Usage: boops fleet known-hosts [output-file]")
+		}
+		output := ""
+		if len(os.Args) == 4 {
+			output = os.Args[3]
+		}
+		handleKnownHosts(output)
 	default:
 		log.Fatal("Unknown command")
 	}
 }
 
 func handleRegist(machineID string) {
-	sysInfo := system.GatherSystemInfo()
+	// A config from an earlier registration may carry an interface filter
+	cfg, err := client.LoadConfig()
+	if err != nil {
+		cfg = &client.Config{}
+	}
+	sysInfo, _ := system.GatherSystemInfo(cfg)
 	sysInfo.ID = machineID
 
 	if err := client.SaveConfig(machineID); err != nil {
@@ -94,10 +116,26 @@ func handleRegist(machineID string) {
 	postJSON(sysInfo)
 }
 
-func handleSync(machineID string) {
-
-	PrintStyledMessage("info", fmt.Sprintf("Operating system: %s", runtime.GOOS))
+// handleKnownHosts writes a known_hosts file covering every machine's
+// hostname and registered IPs to output, or to stdout when output is empty
+func handleKnownHosts(output string) {
+	machines, err := fleet.FetchHostKeys(strings.TrimSuffix(apiBase, "/machines") + "/ssh-host-keys")
+	if err != nil {
+		log.Fatalf("Failed to fetch host keys: %v", err)
+	}
+	knownHosts := fleet.KnownHosts(machines)
+	if output == "" {
+		fmt.Print(knownHosts)
+		return
+	}
+	if err := os.WriteFile(output, []byte(knownHosts), 0644); err != nil {
+		log.Fatalf("Failed to write %s: %v", output, err)
+	}
+	PrintStyledMessage("success", fmt.Sprintf("Wrote host keys of %d machines to %s", len(machines), output))
+}
 
+// fetchMachine retrieves the desired state of the machine from the API
+func fetchMachine(machineID string) client.Machine {
 	resp, err := http.Get(fmt.Sprintf("%s/%s", apiBase, machineID))
 	if err != nil {
 		log.Fatalf("Failed to fetch machine info: %v", err)
@@ -109,118 +147,150 @@ func handleSync(machineID string) {
 	if err := json.Unmarshal(body, &m); err != nil {
 		log.Fatalf("Invalid JSON from API: %v", err)
 	}
+	return m
+}
 
-	// Load previous machine state
+// pendingChanges diffs the desired interfaces against the state saved by the
+// last successful apply; a missing state means everything is new. The bool
+// reports whether any of the changes is one the apply writes.
+func pendingChanges(m client.Machine) (diff.Diff, bool) {
 	prevState, _ := client.LoadMachineState()
-	stateChanged := prevState == nil || !client.InterfacesEqual(prevState.Interfaces, m.Interfaces)
-
-	// Set hostname if changed
-	if m.Hostname != "" && (prevState == nil || prevState.Hostname != m.Hostname) {
-		cmd := fmt.Sprintf("hostnamectl set-hostname %s", m.Hostname)
-		PrintStyledMessage("info", fmt.Sprintf("Setting hostname to: %s", m.Hostname))
-		cmdResult := exec.Command("sh", "-c", cmd)
-		output, err := cmdResult.CombinedOutput()
-		if err != nil {
-			PrintStyledMessage("error", fmt.Sprintf("Failed to set hostname with error: %v, output: %s", err, string(output)))
+	if prevState == nil {
+		return diff.Interfaces(nil, m.Interfaces), true
+	}
+	changes := diff.Interfaces(prevState.Interfaces, m.Interfaces)
+	return changes, !diff.Applied(prevState.Interfaces, m.Interfaces).Empty()
+}
+
+// handlePlan prints what sync would change without touching the host
+func handlePlan(cfg *client.Config) {
+	m := fetchMachine(cfg.ID)
+
+	if err := validate.Machine(m); err != nil {
+		if errs, ok := err.(validate.Errors); ok {
+			fmt.Println("Desired state is invalid and would be rejected:")
+			for _, e := range errs {
+				fmt.Printf("  %s: %s (%q)\n", e.Field, e.Message, e.Value)
+			}
 		} else {
-			PrintStyledMessage("success", "Hostname set successfully")
+			fmt.Printf("Desired state is invalid and would be rejected: %v\n", err)
 		}
+		return
 	}
 
-	// Get current OS name and update it in the server
-	sysInfo := system.GatherSystemInfo()
-	updateOsName := fmt.Sprintf(`{"os_name": "%s"}`, sysInfo.OsName)
-	PrintStyledMessage("info", fmt.Sprintf("Current OS name: %s", updateOsName))
-	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("%s/%s/update-os_name", apiBase, machineID), strings.NewReader(updateOsName))
+	changes, _ := pendingChanges(m)
+	fmt.Println("Changes since last apply:")
+	printDiff(changes)
+
+	live, err := system.GatherNetworkInterfaces()
 	if err != nil {
-		log.Fatalf("Failed to create OS name update request: %v", err)
+		fmt.Printf("Failed to read live network configuration: %v\n", err)
+		return
 	}
-	req.Header.Set("Content-Type", "application/json")
-	respUpdate, err := http.DefaultClient.Do(req)
-	if err != nil {
-		PrintStyledMessage("error", fmt.Sprintf("Failed to send OS name update request: %v", err))
-	} else if respUpdate.StatusCode >= 300 {
-		PrintStyledMessage("warning", fmt.Sprintf("OS name update failed with status code: %d", respUpdate.StatusCode))
-	} else {
-		PrintStyledMessage("success", fmt.Sprintf("Successfully updated OS name to: %s", sysInfo.OsName))
+	fmt.Printf("Drift from live configuration (policy: %s):\n", drift.Policy(cfg.DriftPolicy))
+	items, absent := drift.Detect(m, live)
+	printDiff(items)
+	if len(absent) > 0 {
+		fmt.Printf("Not present on this host: %s\n", strings.Join(absent, ", "))
 	}
+}
 
-	// Update memory size
-	sysInfo = system.GatherSystemInfo()
-	updateMemoryPayload := fmt.Sprintf(`{"memory_size": "%s"}`, sysInfo.MemorySize)
-	PrintStyledMessage("info", fmt.Sprintf("Updating memory size to: %s", sysInfo.MemorySize))
-	req, err = http.NewRequest(http.MethodPut, fmt.Sprintf("%s/%s/update-memory_size", apiBase, machineID), strings.NewReader(updateMemoryPayload))
-	if err != nil {
-		log.Fatalf("Failed to create memory size update request: %v", err)
+func printDiff(d diff.Diff) {
+	if d.Empty() {
+		fmt.Println("  (none)")
+		return
 	}
-	req.Header.Set("Content-Type", "application/json")
-	respMemUpdate, err := http.DefaultClient.Do(req)
-	if err != nil {
-		PrintStyledMessage("error", fmt.Sprintf("Failed to send memory size update request: %v", err))
-	} else if respMemUpdate.StatusCode >= 300 {
-		PrintStyledMessage("warning", fmt.Sprintf("Memory size update failed with status code: %d", respMemUpdate.StatusCode))
-	} else {
-		PrintStyledMessage("success", fmt.Sprintf("Successfully updated memory size to: %s", sysInfo.MemorySize))
+	for _, c := range d {
+		fmt.Printf("  %s\n", c)
 	}
+}
 
-	// Update CPU architecture
-	sysInfo = system.GatherSystemInfo()
-	cpuArchPayload := fmt.Sprintf(`{"cpu_arch": "%s"}`, sysInfo.CpuArch)
-	PrintStyledMessage("info", fmt.Sprintf("Updating CPU architecture to: %s", sysInfo.CpuArch))
-	req, err = http.NewRequest(http.MethodPut, fmt.Sprintf("%s/%s/update-cpu_arch", apiBase, machineID), strings.NewReader(cpuArchPayload))
-	if err != nil {
-		log.Fatalf("Failed to create CPU architecture update request: %v", err)
+func handleSync(cfg *client.Config) {
+	machineID := cfg.ID
+
+	PrintStyledMessage("info", fmt.Sprintf("Operating system: %s", runtime.GOOS))
+
+	m := fetchMachine(machineID)
+
+	// Reject the whole change set if any part of the desired state is invalid
+	validationErr := validate.Machine(m)
+	if validationErr != nil {
+		PrintStyledMessage("error", fmt.Sprintf("Rejecting desired state from server: %v", validationErr))
 	}
-	req.Header.Set("Content-Type", "application/json")
-	respCpuUpdate, err := http.DefaultClient.Do(req)
-	if err != nil {
-		PrintStyledMessage("error", fmt.Sprintf("Failed to send CPU architecture update request: %v", err))
-	} else if respCpuUpdate.StatusCode >= 300 {
-		PrintStyledMessage("warning", fmt.Sprintf("CPU architecture update failed with status code: %d", respCpuUpdate.StatusCode))
-	} else {
-		PrintStyledMessage("success", fmt.Sprintf("Successfully updated CPU architecture to: %s", sysInfo.CpuArch))
+	if err := client.SendReport(apiBase, machineID, "validation", validate.NewReport(validationErr)); err != nil {
+		PrintStyledMessage("warning", fmt.Sprintf("Failed to report validation result: %v", err))
 	}
 
-	// Update CPU model info
-	sysInfo = system.GatherSystemInfo()
-	// Replace newlines with spaces to avoid JSON parsing issues
-	cpuInfo := strings.ReplaceAll(sysInfo.CpuInfo, "\n", " ")
-	cpuInfoPayload := fmt.Sprintf(`{"cpu_info": "%s"}`, cpuInfo)
-	PrintStyledMessage("info", fmt.Sprintf("Updating CPU model info to: %s", cpuInfo))
-	req, err = http.NewRequest(http.MethodPut, fmt.Sprintf("%s/%s/update-cpu_info", apiBase, machineID), strings.NewReader(cpuInfoPayload))
-	if err != nil {
-		log.Fatalf("Failed to create CPU info update request: %v", err)
+	// Compare the desired interfaces with the state saved by the last apply;
+	// only changes to applied settings trigger a new apply
+	changes, stateChanged := pendingChanges(m)
+	for _, c := range changes {
+		PrintStyledMessage("info", fmt.Sprintf("Desired state change: %s", c))
 	}
-	req.Header.Set("Content-Type", "application/json")
-	respCpuModelUpdate, err := http.DefaultClient.Do(req)
-	if err != nil {
-		PrintStyledMessage("error", fmt.Sprintf("Failed to send CPU info update request: %v", err))
-	} else if respCpuModelUpdate.StatusCode >= 300 {
-		PrintStyledMessage("warning", fmt.Sprintf("CPU info update failed with status code: %d", respCpuModelUpdate.StatusCode))
+
+	// Compare the live network configuration with the server record, since
+	// the saved state only reflects what this agent last wrote
+	driftReport := drift.Report{Policy: drift.Policy(cfg.DriftPolicy)}
+	if live, err := system.GatherNetworkInterfaces(); err != nil {
+		PrintStyledMessage("warning", fmt.Sprintf("Failed to read live network configuration: %v", err))
 	} else {
-		PrintStyledMessage("success", fmt.Sprintf("Successfully updated CPU model info to: %s", cpuInfo))
+		driftReport.Items, driftReport.Absent = drift.Detect(m, live)
+		driftReport.Drifted = len(driftReport.Items) > 0
+	}
+	for _, c := range driftReport.Items {
+		PrintStyledMessage("warning", fmt.Sprintf("Drift (desired -> live): %s", c))
 	}
+	for _, name := range driftReport.Absent {
+		PrintStyledMessage("warning", fmt.Sprintf("Interface %s is in the desired state but not present on this host", name))
+	}
+	remediate := driftReport.Drifted && driftReport.Policy == drift.PolicyRemediate
 
-	// Update disk info
-	sysInfo = system.GatherSystemInfo()
-	diskInfo := strings.ReplaceAll(sysInfo.DiskInfo, "\n", " ")
-	diskInfoPayload := fmt.Sprintf(`{"disk_info": "%s"}`, diskInfo)
-	PrintStyledMessage("info", fmt.Sprintf("Updating disk info to: %s", diskInfoPayload))
-	req, err = http.NewRequest(http.MethodPut, fmt.Sprintf("%s/%s/update-disk_info", apiBase, machineID), strings.NewReader(diskInfoPayload))
-	if err != nil {
-		log.Fatalf("Failed to create disk info update request: %v", err)
+	// Make sure the hostname, /etc/hostname and /etc/hosts match the server
+	// record. Only Linux hostnames are managed.
+	if runtime.GOOS == "linux" && m.Hostname != "" && validationErr == nil {
+		changed, err := system.SetHostname(m.Hostname, system.PrimaryIP(m.Interfaces))
+		if err != nil {
+			PrintStyledMessage("error", fmt.Sprintf("Failed to set hostname to %s: %v", m.Hostname, err))
+		} else if changed {
+			PrintStyledMessage("success", fmt.Sprintf("Hostname set to: %s", m.Hostname))
+		}
 	}
-	req.Header.Set("Content-Type", "application/json")
-	respDiskUpdate, err := http.DefaultClient.Do(req)
-	if err != nil {
-		PrintStyledMessage("error", fmt.Sprintf("Failed to send disk info update request: %v", err))
-	} else if respDiskUpdate.StatusCode >= 300 {
-		PrintStyledMessage("warning", fmt.Sprintf("Disk info update failed with status code: %d", respDiskUpdate.StatusCode))
+
+	// Collect the inventory once and push the scalar fields to the server
+	sysInfo, inventoryReport := system.GatherSystemInfo(cfg)
+	updateMachineField(machineID, "os_name", sysInfo.OsName, "OS name")
+	updateMachineField(machineID, "memory_size", sysInfo.MemorySize, "memory size")
+	updateMachineField(machineID, "cpu_arch", sysInfo.CpuArch, "CPU architecture")
+	updateMachineField(machineID, "cpu_info", sysInfo.CpuInfo, "CPU model info")
+	if sysInfo.DiskInfo != nil {
+		updateMachineField(machineID, "disk_info", sysInfo.DiskInfo, "disk info")
+	}
+	if sysInfo.ModelInfo != nil {
+		updateMachineField(machineID, "model_info", sysInfo.ModelInfo, "model info")
+	}
+	if sysInfo.SSHHostKeys != nil {
+		updateMachineField(machineID, "ssh_host_keys", sysInfo.SSHHostKeys, "SSH host keys")
+	}
+
+	// Packages go to their own endpoint as a delta instead of being resent in
+	// full with every inventory report
+	if update, ok := inventoryReport.Data["packages"].(*inventory.PackageUpdate); ok {
+		delete(inventoryReport.Data, "packages")
+		syncPackages(machineID, update)
+	}
+
+	// Upload the full inventory, including which collectors were missing or failed
+	if err := client.SendReport(apiBase, machineID, "inventory", inventoryReport); err != nil {
+		PrintStyledMessage("warning", fmt.Sprintf("Failed to upload inventory: %v", err))
 	} else {
-		PrintStyledMessage("success", "Successfully updated disk info")
+		PrintStyledMessage("success", fmt.Sprintf("Uploaded inventory (%d missing, %d failed)", len(inventoryReport.Missing()), len(inventoryReport.Failed())))
+		// Only now are the changes reported since the last run safe to forget
+		if err := inventoryReport.Commit(); err != nil {
+			PrintStyledMessage("warning", err.Error())
+		}
 	}
 
-	req, err = http.NewRequest(http.MethodPut, fmt.Sprintf("%s/%s/update-last-alive", apiBase, machineID), nil)
+	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("%s/%s/update-last-alive", apiBase, machineID), nil)
 	if err != nil {
 		log.Fatalf("Failed to create request: %v", err)
 	}
@@ -268,9 +338,20 @@ func handleSync(machineID string) {
 		}
 	}
 
+	// Report the switch port seen on every interface the server knows about
+	known := make(map[string]bool)
+	for _, ifaceInfo := range m.Interfaces {
+		known[ifaceInfo.Name] = true
+	}
+	for _, ifaceInfo := range sysInfo.Interfaces {
+		if ifaceInfo.LLDPNeighbors != nil && known[ifaceInfo.Name] {
+			updateInterfaceField(machineID, ifaceInfo.Name, "lldp_neighbors", ifaceInfo.LLDPNeighbors, "LLDP neighbors of "+ifaceInfo.Name)
+		}
+	}
+
 	PrintStyledMessage("info", fmt.Sprintf("Applying network settings for interfaces: %v", m.Interfaces))
 
-	if len(m.Interfaces) > 0 && stateChanged {
+	if len(m.Interfaces) > 0 && (stateChanged || remediate) && validationErr == nil {
 		// Use the actual interface names from the API response
 		ifaceMap := make(map[string]client.InterfaceInfo)
 		for _, ifaceInfo := range m.Interfaces {
@@ -279,8 +360,28 @@ func handleSync(machineID string) {
 			}
 		}
 
+		audit := client.AuditEntry{
+			Action:  "apply_network",
+			Result:  "applied",
+			Changes: map[string]diff.Diff{"desired": changes, "drift": driftReport.Items},
+		}
 		if err := system.ApplyNetworkSettings(ifaceMap); err != nil {
 			PrintStyledMessage("error", fmt.Sprintf("Failed to apply network settings: %v", err))
+			audit.Result, audit.Error = "failed", err.Error()
+		} else if remediate {
+			// Only count it as remediated once the live configuration matches
+			if live, err := system.GatherNetworkInterfaces(); err != nil {
+				PrintStyledMessage("warning", fmt.Sprintf("Failed to re-read live network configuration: %v", err))
+			} else if remaining, _ := drift.Detect(m, live); remaining.Empty() {
+				driftReport.Remediated = true
+			} else {
+				for _, c := range remaining {
+					PrintStyledMessage("warning", fmt.Sprintf("Drift remains after apply: %s", c))
+				}
+			}
+		}
+		if err := client.AppendAuditLog(audit); err != nil {
+			PrintStyledMessage("warning", fmt.Sprintf("Failed to write audit log: %v", err))
 		}
 
 		// Save new state
@@ -295,9 +396,77 @@ func handleSync(machineID string) {
 		}
 	}
 
+	if err := client.SendReport(apiBase, machineID, "drift", driftReport); err != nil {
+		PrintStyledMessage("warning", fmt.Sprintf("Failed to report drift: %v", err))
+	}
+
 	PrintStyledMessage("success", "Sync completed successfully.")
 }
 
+// syncPackages uploads the package changes since the last accepted upload
+func syncPackages(machineID string, update *inventory.PackageUpdate) {
+	if update.Unchanged() {
+		return
+	}
+	err := client.SendPackages(apiBase, machineID, update)
+	if err == client.ErrPackagesOutOfSync {
+		PrintStyledMessage("warning", "Server package list is out of sync; the full list will be sent next sync")
+		if err := inventory.ResetPackages(); err != nil {
+			PrintStyledMessage("warning", fmt.Sprintf("Failed to reset package snapshot: %v", err))
+		}
+		return
+	}
+	if err != nil {
+		PrintStyledMessage("warning", fmt.Sprintf("Failed to upload packages: %v", err))
+		return
+	}
+	if err := update.Commit(); err != nil {
+		PrintStyledMessage("warning", fmt.Sprintf("Failed to save package snapshot: %v", err))
+	}
+	if update.Full {
+		PrintStyledMessage("success", fmt.Sprintf("Uploaded %d packages", update.Count))
+	} else {
+		PrintStyledMessage("success", fmt.Sprintf("Uploaded package changes (%d added, %d removed, %d upgraded)", len(update.Added), len(update.Removed), len(update.Upgraded)))
+	}
+}
+
+// updateMachineField PUTs a single machine field through its update-<field> endpoint
+func updateMachineField(machineID, field string, value interface{}, label string) {
+	putField(fmt.Sprintf("%s/%s/update-%s", apiBase, machineID, field), field, value, label)
+}
+
+// updateInterfaceField PUTs a single interface field through its
+// interfaces/<name>/update-<field> endpoint
+func updateInterfaceField(machineID, ifName, field string, value interface{}, label string) {
+	putField(fmt.Sprintf("%s/%s/interfaces/%s/update-%s", apiBase, machineID, ifName, field), field, value, label)
+}
+
+func putField(url, field string, value interface{}, label string) {
+	payload, _ := json.Marshal(map[string]interface{}{field: value})
+	if s, ok := value.(string); ok {
+		PrintStyledMessage("info", fmt.Sprintf("Updating %s to: %s", label, s))
+	} else {
+		display, _ := json.Marshal(value)
+		PrintStyledMessage("info", fmt.Sprintf("Updating %s to: %s", label, display))
+	}
+	req, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(payload))
+	if err != nil {
+		log.Fatalf("Failed to create %s update request: %v", label, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		PrintStyledMessage("error", fmt.Sprintf("Failed to send %s update request: %v", label, err))
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		PrintStyledMessage("warning", fmt.Sprintf("%s update failed with status code: %d", label, resp.StatusCode))
+	} else {
+		PrintStyledMessage("success", fmt.Sprintf("Successfully updated %s", label))
+	}
+}
+
 func postJSON(data any) {
 	b, _ := json.Marshal(data)
 	req, err := http.NewRequest("POST", fmt.Sprintf("%s/%s", apiBase, data.(client.Machine).ID), strings.NewReader(string(b)))
@@ -312,5 +481,9 @@ func postJSON(data any) {
 		log.Fatalf("POST failed: %v", err)
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		log.Fatalf("Registration failed with status code %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
 	log.Println("Registered successfully.")
 }
@@ -0,0 +1,17 @@
+package system
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// runCommand runs name with args as an argv vector (never through a shell)
+// and folds the combined output into the error on failure
+func runCommand(name string, args ...string) ([]byte, error) {
+	output, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return output, fmt.Errorf("%s failed with error: %v, output: %s", name, err, strings.TrimSpace(string(output)))
+	}
+	return output, nil
+}
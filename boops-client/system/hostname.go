@@ -0,0 +1,244 @@
+package system
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"boops/client"
+	"boops/validate"
+)
+
+var (
+	hostnamePath = "/etc/hostname"
+	hostsPath    = "/etc/hosts"
+)
+
+// hostsLoopbackIP is the Debian convention for mapping the host's own name
+// when it has no (or no stable) primary address
+const hostsLoopbackIP = "127.0.1.1"
+
+// Hostname holds the short and fully qualified names of a host
+type Hostname struct {
+	Short string
+	FQDN  string
+}
+
+// ParseHostname splits name into its short name and FQDN. A name without a
+// domain part is its own FQDN.
+func ParseHostname(name string) (Hostname, error) {
+	name = strings.TrimSuffix(strings.TrimSpace(name), ".")
+	if err := validate.Hostname(name); err != nil {
+		return Hostname{}, err
+	}
+	short, _, _ := strings.Cut(name, ".")
+	return Hostname{Short: short, FQDN: name}, nil
+}
+
+// names returns the aliases written to /etc/hosts, FQDN first as expected by
+// hostname --fqdn
+func (h Hostname) names() []string {
+	if h.FQDN == h.Short {
+		return []string{h.Short}
+	}
+	return []string{h.FQDN, h.Short}
+}
+
+// PrimaryIP picks the address the hostname should resolve to: the first IP of
+// the interface carrying a gateway, falling back to the first IP found
+func PrimaryIP(ifaces []client.InterfaceInfo) string {
+	fallback := ""
+	for _, info := range ifaces {
+		if len(info.IPs) == 0 {
+			continue
+		}
+		if info.Gateway != "" && info.Gateway != "0.0.0.0" {
+			return info.IPs[0].IP
+		}
+		if fallback == "" {
+			fallback = info.IPs[0].IP
+		}
+	}
+	return fallback
+}
+
+// SetHostname applies name as the static hostname without relying on
+// systemd: it sets the kernel hostname, writes /etc/hostname and maintains the
+// 127.0.1.1 and primary IP entries in /etc/hosts. It reports whether anything
+// had to be changed and verifies the result before returning.
+func SetHostname(name, primaryIP string) (bool, error) {
+	h, err := ParseHostname(name)
+	if err != nil {
+		return false, err
+	}
+
+	// Names the host had before, to be moved off stale /etc/hosts lines
+	var stale []string
+	previous, _ := os.ReadFile(hostnamePath)
+	current, err := os.Hostname()
+	for _, name := range []string{current, strings.TrimSpace(string(previous))} {
+		if name != "" && name != h.Short && !strings.HasPrefix(name, "localhost") {
+			stale = append(stale, name)
+		}
+	}
+
+	changed := false
+	if err != nil || current != h.Short {
+		if err := setKernelHostname(h.Short); err != nil {
+			return false, fmt.Errorf("failed to set kernel hostname: %v", err)
+		}
+		changed = true
+	}
+
+	existing, err := os.ReadFile(hostnamePath)
+	if err != nil && !os.IsNotExist(err) {
+		return changed, fmt.Errorf("failed to read %s: %v", hostnamePath, err)
+	}
+	if strings.TrimSpace(string(existing)) != h.Short {
+		if err := writeFileAtomic(hostnamePath, []byte(h.Short+"\n"), 0644); err != nil {
+			return changed, fmt.Errorf("failed to write %s: %v", hostnamePath, err)
+		}
+		changed = true
+	}
+
+	hosts, err := os.ReadFile(hostsPath)
+	if err != nil && !os.IsNotExist(err) {
+		return changed, fmt.Errorf("failed to read %s: %v", hostsPath, err)
+	}
+	updated := updateHostsContent(string(hosts), h, primaryIP, stale)
+	if updated != string(hosts) {
+		if err := writeFileAtomic(hostsPath, []byte(updated), 0644); err != nil {
+			return changed, fmt.Errorf("failed to write %s: %v", hostsPath, err)
+		}
+		changed = true
+	}
+
+	return changed, verifyHostname(h, primaryIP)
+}
+
+// updateHostsContent makes the 127.0.1.1 and primary IP lines of an
+// /etc/hosts file map to h, keeping any other aliases on them. Our names and
+// the previous hostnames in stale are removed from the lines of every other
+// non-loopback address, such as a former primary IP; lines left without
+// aliases are dropped. Other lines are left untouched.
+func updateHostsContent(content string, h Hostname, primaryIP string, stale []string) string {
+	managed := map[string]bool{hostsLoopbackIP: true}
+	if primaryIP != "" {
+		managed[primaryIP] = true
+	}
+	ours := h.names()
+	// Only exact names count: db.staging.example.com may well be another
+	// host's entry when we are db
+	isOurs := func(alias string) bool {
+		for _, name := range append(ours, stale...) {
+			if strings.EqualFold(alias, name) {
+				return true
+			}
+		}
+		return false
+	}
+
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	if content == "" {
+		lines = nil
+	}
+
+	var result []string
+	written := make(map[string]bool)
+	for _, line := range lines {
+		entry, comment, _ := strings.Cut(line, "#")
+		fields := strings.Fields(entry)
+		if len(fields) == 0 {
+			result = append(result, line)
+			continue
+		}
+		ip := fields[0]
+		if !managed[ip] {
+			if parsed := net.ParseIP(ip); parsed == nil || parsed.IsLoopback() {
+				result = append(result, line)
+				continue
+			}
+		}
+
+		var others []string
+		for _, alias := range fields[1:] {
+			if !isOurs(alias) {
+				others = append(others, alias)
+			}
+		}
+		var aliases []string
+		if managed[ip] && !written[ip] {
+			aliases = append(aliases, ours...)
+			written[ip] = true
+		}
+		aliases = append(aliases, others...)
+		switch {
+		case len(aliases) == len(fields)-1 && !managed[ip]:
+			result = append(result, line) // Nothing of ours on it
+		case len(aliases) > 0:
+			result = append(result, hostsLine(ip, aliases, comment))
+		}
+	}
+
+	for _, ip := range []string{hostsLoopbackIP, primaryIP} {
+		if ip != "" && !written[ip] {
+			result = append(result, hostsLine(ip, ours, ""))
+			written[ip] = true
+		}
+	}
+	return strings.Join(result, "\n") + "\n"
+}
+
+func hostsLine(ip string, aliases []string, comment string) string {
+	line := fmt.Sprintf("%s\t%s", ip, strings.Join(aliases, " "))
+	if comment != "" {
+		line += " #" + comment
+	}
+	return line
+}
+
+func stripHostsComment(line string) string {
+	if i := strings.IndexByte(line, '#'); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
+
+// verifyHostname re-reads the kernel hostname and the files written by
+// SetHostname and reports any mismatch
+func verifyHostname(h Hostname, primaryIP string) error {
+	current, err := os.Hostname()
+	if err != nil {
+		return fmt.Errorf("failed to read back hostname: %v", err)
+	}
+	if current != h.Short {
+		return fmt.Errorf("hostname is %q after update, expected %q", current, h.Short)
+	}
+
+	data, err := os.ReadFile(hostnamePath)
+	if err != nil {
+		return fmt.Errorf("failed to read back %s: %v", hostnamePath, err)
+	}
+	if strings.TrimSpace(string(data)) != h.Short {
+		return fmt.Errorf("%s contains %q, expected %q", hostnamePath, strings.TrimSpace(string(data)), h.Short)
+	}
+
+	data, err = os.ReadFile(hostsPath)
+	if err != nil {
+		return fmt.Errorf("failed to read back %s: %v", hostsPath, err)
+	}
+	mapped := make(map[string]bool)
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(stripHostsComment(line))
+		if len(fields) > 1 && fields[1] == h.names()[0] {
+			mapped[fields[0]] = true
+		}
+	}
+	for _, ip := range []string{hostsLoopbackIP, primaryIP} {
+		if ip != "" && !mapped[ip] {
+			return fmt.Errorf("%s does not map %s to %s", hostsPath, ip, h.FQDN)
+		}
+	}
+	return nil
+}
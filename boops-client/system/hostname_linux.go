@@ -0,0 +1,8 @@
+package system
+
+import "syscall"
+
+// setKernelHostname sets the running hostname through sethostname(2)
+func setKernelHostname(name string) error {
+	return syscall.Sethostname([]byte(name))
+}
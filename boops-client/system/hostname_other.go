@@ -0,0 +1,12 @@
+//go:build !linux
+
+package system
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func setKernelHostname(name string) error {
+	return fmt.Errorf("setting the hostname is not supported on %s", runtime.GOOS)
+}
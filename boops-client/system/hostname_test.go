@@ -0,0 +1,98 @@
+package system
+
+import "testing"
+
+func TestUpdateHostsContent(t *testing.T) {
+	h := Hostname{Short: "db", FQDN: "db.example.com"}
+	tests := []struct {
+		name      string
+		content   string
+		primaryIP string
+		stale     []string
+		want      string
+	}{
+		{
+			name:      "missing file",
+			content:   "",
+			primaryIP: "10.0.0.5",
+			want:      "127.0.1.1\tdb.example.com db\n10.0.0.5\tdb.example.com db\n",
+		},
+		{
+			name:    "no primary IP",
+			content: "127.0.0.1\tlocalhost\n",
+			want:    "127.0.0.1\tlocalhost\n127.0.1.1\tdb.example.com db\n",
+		},
+		{
+			name: "unrelated FQDN aliases on other IPs",
+			content: "127.0.0.1\tlocalhost\n" +
+				"10.0.0.9\tdb.staging.example.com\n" +
+				"10.0.0.10\tdb-replica db.backup\n",
+			primaryIP: "10.0.0.5",
+			want: "127.0.0.1\tlocalhost\n" +
+				"10.0.0.9\tdb.staging.example.com\n" +
+				"10.0.0.10\tdb-replica db.backup\n" +
+				"127.0.1.1\tdb.example.com db\n" +
+				"10.0.0.5\tdb.example.com db\n",
+		},
+		{
+			name: "managed lines keep other aliases",
+			content: "127.0.1.1\told.example.com old\n" +
+				"10.0.0.5\told vip.example.com\n",
+			primaryIP: "10.0.0.5",
+			stale:     []string{"old", "old.example.com"},
+			want: "127.0.1.1\tdb.example.com db\n" +
+				"10.0.0.5\tdb.example.com db vip.example.com\n",
+		},
+		{
+			name: "former primary IP",
+			content: "127.0.1.1\tdb.example.com db\n" +
+				"10.0.0.4\tdb.example.com db\n" +
+				"10.0.0.3\tdb backup.example.com\n",
+			primaryIP: "10.0.0.5",
+			want: "127.0.1.1\tdb.example.com db\n" +
+				"10.0.0.3\tbackup.example.com\n" +
+				"10.0.0.5\tdb.example.com db\n",
+		},
+		{
+			name: "renamed host",
+			content: "127.0.1.1\tweb\n" +
+				"10.0.0.5\tweb\n",
+			primaryIP: "10.0.0.5",
+			stale:     []string{"web"},
+			want: "127.0.1.1\tdb.example.com db\n" +
+				"10.0.0.5\tdb.example.com db\n",
+		},
+		{
+			name: "comments",
+			content: "# static entries\n" +
+				"127.0.0.1\tlocalhost # loopback\n" +
+				"10.0.0.5\tdb # primary\n" +
+				"# 10.0.0.4 db\n",
+			primaryIP: "10.0.0.5",
+			want: "# static entries\n" +
+				"127.0.0.1\tlocalhost # loopback\n" +
+				"10.0.0.5\tdb.example.com db # primary\n" +
+				"# 10.0.0.4 db\n" +
+				"127.0.1.1\tdb.example.com db\n",
+		},
+		{
+			name:      "IPv6 loopback untouched",
+			content:   "::1\tlocalhost ip6-localhost db\n",
+			primaryIP: "10.0.0.5",
+			want: "::1\tlocalhost ip6-localhost db\n" +
+				"127.0.1.1\tdb.example.com db\n" +
+				"10.0.0.5\tdb.example.com db\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := updateHostsContent(tt.content, h, tt.primaryIP, tt.stale)
+			if got != tt.want {
+				t.Errorf("updateHostsContent() = %q, want %q", got, tt.want)
+			}
+			if again := updateHostsContent(got, h, tt.primaryIP, tt.stale); again != got {
+				t.Errorf("second updateHostsContent() = %q, want %q", again, got)
+			}
+		})
+	}
+}
@@ -1,114 +1,215 @@
 package system
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"net"
+	"os"
 	"os/exec"
 	"runtime"
+	"strconv"
 	"strings"
+	"time"
 
 	"boops/client"
+	"boops/inventory"
 )
 
-func GatherSystemInfo() client.Machine {
-	return client.Machine{
-		Hostname:   getHostname(),
-		OsName:     getOSInfo(),
-		CpuInfo:    getCPUModel(),
-		CpuArch:    runtime.GOARCH,
-		MemorySize: getMemorySize(),
-		DiskInfo:   getDiskInfo(),
-		Interfaces: getInterfaces(),
-		IsVirtual:  isVirtual(),
-	}
-}
+// fullThreshold is the usage percentage above which a filesystem is warned
+// about during sync
+const fullThreshold = 90
+
+// fsRoot is the filesystem root the native Linux collectors read from
+var fsRoot inventory.Root = "/"
 
-func getHostname() string {
-	host, _ := exec.Command("hostname").Output()
-	return strings.TrimSpace(string(host))
+// collectTimeout bounds each collector so one hung source (e.g. a dead SAN
+// path) cannot block the whole sync
+var collectTimeout = 15 * time.Second
+
+// GatherInventory runs every collector for the current OS in parallel. cfg
+// may be nil.
+func GatherInventory(ctx context.Context, cfg *client.Config) *inventory.Report {
+	registry := inventory.NewRegistry(runtime.GOOS, collectTimeout)
+	registry.Register(inventory.Default(fsRoot)...)
+	registry.Register(inventory.NewLLDPCollector(fsRoot, cfg != nil && cfg.LLDPListen))
+	registry.Register(windowsCollectors()...)
+	return registry.Run(ctx)
 }
 
-func getOSInfo() string {
-	if runtime.GOOS == "windows" {
-		out, _ := exec.Command("cmd", "/C", "ver").Output()
-		return strings.TrimSpace(string(out))
+// GatherSystemInfo builds the machine record from a fresh inventory run and
+// returns the run's report alongside it. cfg selects the interfaces and
+// may be nil.
+func GatherSystemInfo(cfg *client.Config) (client.Machine, *inventory.Report) {
+	report := GatherInventory(context.Background(), cfg)
+	for _, name := range report.Failed() {
+		PrintStyledMessage("warning", fmt.Sprintf("Failed to collect %s: %s", name, report.Results[name].Error))
 	}
-	out, _ := exec.Command("sh", "-c", "lsb_release -d | sed 's/\\t/ /g'").Output()
-	if len(out) == 0 {
-		out, _ = exec.Command("uname", "-a").Output()
+
+	m := client.Machine{
+		OsName:     stringData(report, "os"),
+		CpuInfo:    stringData(report, "cpu_model"),
+		CpuArch:    runtime.GOARCH,
+		MemorySize: "0",
+		Interfaces: getInterfaces(cfg.Filter()),
 	}
-	return strings.TrimSpace(string(out))
-}
 
-func getCPUModel() string {
-	if runtime.GOOS == "windows" {
-		out, _ := exec.Command("wmic", "cpu", "get", "Name").Output()
-		lines := strings.Split(string(out), "\n")
-		if len(lines) > 1 {
-			return strings.TrimSpace(lines[1])
+	m.Hostname = stringData(report, "hostname")
+	if m.Hostname == "" {
+		m.Hostname, _ = os.Hostname()
+	}
+	if mem, ok := report.Data["memory"].(*inventory.Memory); ok {
+		m.MemorySize = strconv.FormatUint(mem.Total, 10)
+	}
+	if filesystems, ok := report.Data["filesystems"].([]inventory.Filesystem); ok {
+		for _, fs := range filesystems {
+			if fs.Unresponsive {
+				PrintStyledMessage("warning", fmt.Sprintf("Filesystem %s (%s) is not responding", fs.Mountpoint, fs.Device))
+				continue
+			}
+			if fs.UsedPercent >= fullThreshold {
+				PrintStyledMessage("warning", fmt.Sprintf("Filesystem %s is %.1f%% full", fs.Mountpoint, fs.UsedPercent))
+			}
+			if fs.Inodes > 0 && fs.InodesUsed*100 >= fs.Inodes*fullThreshold {
+				PrintStyledMessage("warning", fmt.Sprintf("Filesystem %s is running out of inodes (%d of %d used)", fs.Mountpoint, fs.InodesUsed, fs.Inodes))
+			}
 		}
-		return "Unknown"
 	}
-	out, _ := exec.Command("sh", "-c", "lscpu | grep 'Model name' | cut -d: -f2 | sed 's/^[[:space:]]\\+//'").Output()
-	return strings.TrimSpace(string(out))
-}
-
-func getMemorySize() string {
-	if runtime.GOOS == "windows" {
-		out, _ := exec.Command("wmic", "ComputerSystem", "get", "TotalPhysicalMemory").Output()
-		lines := strings.Split(string(out), "\n")
-		if len(lines) > 1 {
-			bytes := strings.TrimSpace(lines[1])
-			return fmt.Sprintf("%dGB", toGB(bytes))
+	if storage, ok := report.Data["storage"].(*inventory.Storage); ok && len(storage.Degraded) > 0 {
+		PrintStyledMessage("warning", fmt.Sprintf("Degraded storage: %s", strings.Join(storage.Degraded, ", ")))
+	}
+	if neighbors, ok := report.Data["lldp"].(inventory.LLDPNeighbors); ok {
+		for i, iface := range m.Interfaces {
+			// An empty list clears neighbors that are no longer seen
+			found := []inventory.LLDPNeighbor{}
+			for _, n := range neighbors[iface.Name] {
+				PrintStyledMessage("info", fmt.Sprintf("%s is connected to %s port %s", iface.Name, lldpName(n), n.PortID))
+				found = append(found, n)
+			}
+			m.Interfaces[i].LLDPNeighbors = found
 		}
-		return "0GB"
 	}
-
-	// Use free -b for bytes, then convert to GB with proper handling of decimal values
-	out, _ := exec.Command("sh", "-c", "free -b | grep Mem").Output()
-	lines := strings.Split(string(out), "\n")
-	if len(lines) > 0 {
-		fields := strings.Fields(strings.TrimSpace(lines[0]))
-		if len(fields) >= 2 {
-			var bytes int64
-			fmt.Sscanf(fields[1], "%d", &bytes)
-			gb := float64(bytes) / (1024 * 1024 * 1024)
-			return fmt.Sprintf("%.1fGB", gb)
+	if services, ok := report.Data["services"].(*inventory.Services); ok && len(services.Failed) > 0 {
+		PrintStyledMessage("warning", fmt.Sprintf("Failed units: %s", strings.Join(services.Failed, ", ")))
+	}
+	if sshd, ok := report.Data["sshd"].(*inventory.SSHDConfig); ok && sshd.PermitRootLogin == "yes" && sshd.PasswordAuthentication {
+		PrintStyledMessage("warning", "sshd allows root to log in with a password")
+	}
+	if sockets, ok := report.Data["sockets"].(*inventory.ListeningSockets); ok {
+		for _, s := range sockets.Opened {
+			PrintStyledMessage("warning", fmt.Sprintf("New listening socket: %s", socketLabel(s)))
+		}
+		for _, s := range sockets.Closed {
+			PrintStyledMessage("info", fmt.Sprintf("Socket no longer listening: %s", socketLabel(s)))
+		}
+	}
+	if usb, ok := report.Data["usb"].(*inventory.USBInventory); ok {
+		for _, dev := range usb.Attached {
+			PrintStyledMessage("info", fmt.Sprintf("USB device attached at %s: %s:%s %s", dev.Path, dev.VendorID, dev.ProductID, dev.Product))
+		}
+		for _, dev := range usb.Detached {
+			PrintStyledMessage("warning", fmt.Sprintf("USB device detached from %s: %s:%s %s", dev.Path, dev.VendorID, dev.ProductID, dev.Product))
 		}
 	}
-	return "0GB"
+	if disks, ok := report.Data["disks"].([]inventory.Disk); ok && len(disks) > 0 {
+		m.DiskInfo = disks
+	}
+	if virtual, _ := report.Data["virtual"].(bool); virtual {
+		m.IsVirtual = 1
+	}
+	if model, ok := report.Data["dmi"].(*inventory.ModelInfo); ok {
+		m.ModelInfo = model
+	}
+	if keys, ok := report.Data["ssh_host_keys"].([]inventory.HostKey); ok && len(keys) > 0 {
+		m.SSHHostKeys = keys
+	}
+	return m, report
 }
 
-func getDiskInfo() string {
-	if runtime.GOOS == "windows" {
-		out, _ := exec.Command("wmic", "logicaldisk", "get", "Caption,Size").Output()
-		lines := strings.Split(string(out), "\n")
-		var results []string
-		for _, line := range lines[1:] {
-			tokens := strings.Fields(line)
-			if len(tokens) == 2 {
-				size := toGB(tokens[1])
-				results = append(results, fmt.Sprintf("%s : %dGB", tokens[0], size))
+func stringData(report *inventory.Report, name string) string {
+	s, _ := report.Data[name].(string)
+	return s
+}
+
+// windowsCollectors wrap the wmic based lookups used on Windows hosts
+func windowsCollectors() []inventory.Collector {
+	windows := []string{"windows"}
+	return []inventory.Collector{
+		inventory.NewCollector("hostname", windows, func(ctx context.Context) (interface{}, error) {
+			return os.Hostname()
+		}),
+		inventory.NewCollector("os", windows, func(ctx context.Context) (interface{}, error) {
+			out, err := exec.CommandContext(ctx, "cmd", "/C", "ver").Output()
+			if err != nil {
+				return nil, err
 			}
-		}
-		return strings.Join(results, "\n")
+			return strings.TrimSpace(string(out)), nil
+		}),
+		inventory.NewCollector("cpu_model", windows, func(ctx context.Context) (interface{}, error) {
+			return wmicValue(ctx, "cpu", "get", "Name")
+		}),
+		inventory.NewCollector("memory", windows, func(ctx context.Context) (interface{}, error) {
+			value, err := wmicValue(ctx, "ComputerSystem", "get", "TotalPhysicalMemory")
+			if err != nil {
+				return nil, err
+			}
+			total, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			return &inventory.Memory{Total: total}, nil
+		}),
+		inventory.NewCollector("disks", windows, func(ctx context.Context) (interface{}, error) {
+			out, err := exec.CommandContext(ctx, "wmic", "logicaldisk", "get", "Caption,Size").Output()
+			if err != nil {
+				return nil, err
+			}
+			var disks []inventory.Disk
+			for _, line := range strings.Split(string(out), "\n")[1:] {
+				tokens := strings.Fields(line)
+				if len(tokens) == 2 {
+					size, _ := strconv.ParseUint(tokens[1], 10, 64)
+					disks = append(disks, inventory.Disk{Name: tokens[0], Size: size})
+				}
+			}
+			return disks, nil
+		}),
 	}
-	out, _ := exec.Command("sh", "-c", "lsblk -b -o NAME,SIZE -dn | awk '{printf(\"/dev/%s : %.0fGB\\n\", $1, $2/1024/1024/1024)}'").Output()
-	return strings.TrimSpace(string(out))
 }
 
-func getInterfaces() []client.InterfaceInfo {
+// wmicValue returns the first value line of a "wmic ... get <Field>" query
+func wmicValue(ctx context.Context, args ...string) (string, error) {
+	out, err := exec.CommandContext(ctx, "wmic", args...).Output()
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(string(out), "\n")
+	if len(lines) < 2 || strings.TrimSpace(lines[1]) == "" {
+		return "", fmt.Errorf("wmic %s returned no value", strings.Join(args, " "))
+	}
+	return strings.TrimSpace(lines[1]), nil
+}
+
+// getInterfaces lists the interfaces with IPv4 addresses that pass filter,
+// with their live MAC, gateway and DNS servers
+func getInterfaces(filter client.InterfaceFilter) []client.InterfaceInfo {
 	out, _ := exec.Command("ip", "-j", "addr").Output()
 	var data []map[string]interface{}
 	json.Unmarshal(out, &data)
 	result := make([]client.InterfaceInfo, 0)
+	gateways, dns := interfaceRouting()
 
 	for _, ifaceData := range data {
-		name := ifaceData["ifname"].(string)
+		name, _ := ifaceData["ifname"].(string)
 		if name == "" { // Skip empty interface names
 			continue
 		}
 
+		nic := inventory.ReadNIC(fsRoot, name)
+		if !filter.Allows(name, nic.Kind, interfaceUp(nic, ifaceData)) {
+			continue
+		}
+
 		var ipInfos []client.IPInfo
 
 		if addrs, ok := ifaceData["addr_info"].([]interface{}); ok && len(addrs) > 0 {
@@ -117,9 +218,9 @@ func getInterfaces() []client.InterfaceInfo {
 				local := addrMap["local"].(string)
 				prefixlen := int(addrMap["prefixlen"].(float64))
 
-				// Ensure prefix length is valid
-				if prefixlen < 0 || prefixlen > 32 {
-					continue // Skip invalid addresses
+				// Only IPv4 addresses are registered
+				if addrMap["family"] != "inet" || prefixlen < 0 || prefixlen > 32 {
+					continue
 				}
 
 				subnet := cidrToMask(prefixlen)
@@ -133,10 +234,12 @@ func getInterfaces() []client.InterfaceInfo {
 
 		if len(ipInfos) > 0 { // Only include interfaces with valid IP addresses
 			result = append(result, client.InterfaceInfo{
+				Name:       name,
 				IPs:        ipInfos,
-				Gateway:    "",
-				DnsServers: "",   // Empty string instead of slice
-				MacAddress: name, // Use interface name as ID for now
+				Gateway:    gateways[name],
+				DnsServers: dns(name),
+				MacAddress: nic.MAC,
+				Mtu:        nic.MTU,
 			})
 		}
 	}
@@ -144,17 +247,38 @@ func getInterfaces() []client.InterfaceInfo {
 	return result
 }
 
-func isVirtual() int {
-	out, _ := exec.Command("systemd-detect-virt").Output()
-	virtType := strings.TrimSpace(string(out))
-	if virtType == "" || virtType == "none" {
-		return 0
+// interfaceUp reports whether an interface is up. Loopback and tunnel
+// interfaces have no carrier and report operstate "unknown", so their UP
+// flag decides.
+func interfaceUp(nic inventory.NIC, ifaceData map[string]interface{}) bool {
+	if nic.OperState != "unknown" {
+		return nic.OperState == "up"
 	}
-	return 1
+	flags, _ := ifaceData["flags"].([]interface{})
+	for _, flag := range flags {
+		if flag == "UP" {
+			return true
+		}
+	}
+	return false
+}
+
+// lldpName names a neighbor by system name, falling back to its chassis ID
+func lldpName(n inventory.LLDPNeighbor) string {
+	if n.SystemName != "" {
+		return n.SystemName
+	}
+	return n.ChassisID
 }
 
-func toGB(raw string) int64 {
-	var b int64
-	fmt.Sscanf(raw, "%d", &b)
-	return b / 1024 / 1024 / 1024
+// socketLabel describes a listening socket and its owner for sync messages
+func socketLabel(s inventory.Socket) string {
+	label := fmt.Sprintf("%s %s", s.Protocol, net.JoinHostPort(s.Address, strconv.Itoa(s.Port)))
+	switch {
+	case s.Unit != "":
+		label += fmt.Sprintf(" (%s, %s)", s.Process, s.Unit)
+	case s.Process != "":
+		label += fmt.Sprintf(" (%s)", s.Process)
+	}
+	return label
 }
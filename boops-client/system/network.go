@@ -3,11 +3,18 @@ package system
 import (
 	"encoding/json"
 	"fmt"
+	"net"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 
 	"boops/client"
+	"boops/diff"
+	"boops/inventory"
+	"boops/validate"
 )
 
 // ANSI color codes for styled output
@@ -72,6 +79,19 @@ func ApplyNetworkSettings(ifaceArg interface{}) error {
 	if len(ifaces) == 0 {
 		return fmt.Errorf("no network interfaces provided")
 	}
+	// A gateway of 0.0.0.0 is how the server records "no gateway", as
+	// validate.Machine treats it
+	normalized := make(map[string]client.InterfaceInfo, len(ifaces))
+	for name, info := range ifaces {
+		if info.Gateway == "0.0.0.0" {
+			info.Gateway = ""
+		}
+		if err := validateInterfaceSettings(name, info); err != nil {
+			return fmt.Errorf("refusing to apply settings for interface %q: %v", name, err)
+		}
+		normalized[name] = info
+	}
+	ifaces = normalized
 
 	if runtime.GOOS == "linux" {
 		return applyLinux(ifaces)
@@ -89,9 +109,7 @@ func applyLinux(ifaces map[string]client.InterfaceInfo) error {
 
 	for name, info := range ifaces {
 		// Check if interface exists
-		cmd := exec.Command("ip", "link", "show", name)
-		output, err := cmd.CombinedOutput()
-		if err != nil || strings.Contains(string(output), "Device does not exist") {
+		if _, err := os.Stat(filepath.Join("/sys/class/net", name)); err != nil {
 			return fmt.Errorf("interface %s does not exist on this system", name)
 		}
 
@@ -115,24 +133,24 @@ func isDebianSystem() (bool, bool, error) {
 	var usesInterfacesFile bool
 
 	// Check for /etc/network/interfaces file first as a primary indicator of configuration method
-	if _, err := exec.Command("test", "-f", "/etc/network/interfaces").CombinedOutput(); err == nil {
+	if fileExists("/etc/network/interfaces") {
 		usesInterfacesFile = true
 	} else {
 		// Check for Debian-based system
-		if _, err := exec.Command("test", "-f", "/etc/debian_version").CombinedOutput(); err == nil {
+		if fileExists("/etc/debian_version") {
 			isDebian = true
 
 			// For Debian systems, check if netplan is available instead of /etc/network/interfaces
-			if _, err := exec.Command("which", "netplan").CombinedOutput(); err == nil {
+			if _, err := exec.LookPath("netplan"); err == nil {
 				usesInterfacesFile = false // Use Netplan instead
 			} else {
 				usesInterfacesFile = true // Default to interfaces file for Debian
 			}
-		} else if _, err := exec.Command("test", "-f", "/etc/redhat-release").CombinedOutput(); err == nil {
+		} else if fileExists("/etc/redhat-release") {
 			isDebian = false
 
 			// For RedHat systems, check if nmcli is available instead of /etc/network/interfaces
-			if _, err := exec.Command("which", "nmcli").CombinedOutput(); err == nil {
+			if _, err := exec.LookPath("nmcli"); err == nil {
 				usesInterfacesFile = false // Use nmcli instead
 			} else {
 				usesInterfacesFile = true // Default to interfaces file for RedHat
@@ -146,6 +164,11 @@ func isDebianSystem() (bool, bool, error) {
 	return isDebian, usesInterfacesFile, nil
 }
 
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
 func applyNetplan(iface string, info client.InterfaceInfo) error {
 	configPath := "/etc/netplan/01-netcfg.yaml"
 	// IP アドレスとサブネットを CIDR 形式で連結
@@ -159,13 +182,7 @@ func applyNetplan(iface string, info client.InterfaceInfo) error {
 	}
 
 	// DNS サーバをスライスに変換
-	var dnsList []string
-	for _, dns := range strings.Split(info.DnsServers, ",") {
-		trimmed := strings.TrimSpace(dns)
-		if trimmed != "" {
-			dnsList = append(dnsList, trimmed)
-		}
-	}
+	dnsList := diff.SplitList(info.DnsServers)
 
 	// YAML 生成
 	content := fmt.Sprintf(`network:
@@ -174,46 +191,33 @@ func applyNetplan(iface string, info client.InterfaceInfo) error {
     %s:
       dhcp4: no
       addresses: [%s]
-      gateway4: %s
-      nameservers:
-        addresses: [%s]
-`, iface, strings.Join(addresses, ", "), info.Gateway, strings.Join(dnsList, ", "))
-
-	// Remove all existing netplan configurations to avoid conflicts
-	var cmd *exec.Cmd
-	var output []byte
-	var err error
-
-	cmd = exec.Command("sh", "-c", "rm -f /etc/netplan/*.yaml")
-	output, err = cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("failed to remove existing netplan configs: %v, output: %s", err, string(output))
+`, iface, strings.Join(addresses, ", "))
+	if info.Gateway != "" {
+		content += fmt.Sprintf("      gateway4: %s\n", info.Gateway)
 	}
-
-	err = writeNetplanConfig(configPath, content)
-	if err != nil {
-		return fmt.Errorf("failed to write netplan config: %v", err)
+	content += fmt.Sprintf("      nameservers:\n        addresses: [%s]\n", strings.Join(dnsList, ", "))
+	if info.Mtu > 0 {
+		content += fmt.Sprintf("      mtu: %d\n", info.Mtu)
 	}
 
-	cmd = exec.Command("sh", "-c", "chmod 600 "+configPath)
-	output, err = cmd.CombinedOutput()
+	// Remove all existing netplan configurations to avoid conflicts
+	existing, err := filepath.Glob("/etc/netplan/*.yaml")
 	if err != nil {
-		return fmt.Errorf("failed to set file permissions: %v, output: %s", err, string(output))
+		return fmt.Errorf("failed to list existing netplan configs: %v", err)
+	}
+	for _, path := range existing {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove existing netplan config %s: %v", path, err)
+		}
 	}
 
-	cmd = exec.Command("sh", "-c", "netplan apply")
-	output, err = cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("netplan apply failed with error: %v, output: %s", err, string(output))
+	// netplan refuses world-readable configs, so write it 0600 from the start
+	if err := writeFileAtomic(configPath, []byte(content), 0600); err != nil {
+		return fmt.Errorf("failed to write netplan config: %v", err)
 	}
-	return nil
-}
 
-func writeNetplanConfig(path, content string) error {
-	cmd := exec.Command("sh", "-c", fmt.Sprintf("echo '%s' | tee %s > /dev/null", content, path))
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("failed to write config: %v, output: %s", err, string(output))
+	if _, err := runCommand("netplan", "apply"); err != nil {
+		return err
 	}
 	return nil
 }
@@ -248,7 +252,7 @@ func applyInterfacesFile(iface string, info client.InterfaceInfo) error {
 			}
 
 			// ブロック内で address / gateway はスキップ（後で再挿入）
-			if strings.HasPrefix(trimmed, "address") || strings.HasPrefix(trimmed, "gateway") {
+			if strings.HasPrefix(trimmed, "address") || strings.HasPrefix(trimmed, "gateway") || (info.Mtu > 0 && strings.HasPrefix(trimmed, "mtu")) {
 				continue
 			}
 		}
@@ -271,6 +275,9 @@ func applyInterfacesFile(iface string, info client.InterfaceInfo) error {
 	if info.Gateway != "" && info.Gateway != "0.0.0.0" {
 		insertLines = append(insertLines, fmt.Sprintf("    gateway %s", info.Gateway))
 	}
+	if info.Mtu > 0 {
+		insertLines = append(insertLines, fmt.Sprintf("    mtu %d", info.Mtu))
+	}
 
 	// iface ブロックに address/gateway を挿入
 	newLines = insertIntoIfaceBlock(newLines, iface, insertLines)
@@ -282,10 +289,8 @@ func applyInterfacesFile(iface string, info client.InterfaceInfo) error {
 	}
 
 	// ネットワーク再起動
-	cmd := exec.Command("sh", "-c", "systemctl restart networking")
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("networking service restart failed: %v, output: %s", err, string(output))
+	if _, err := runCommand("systemctl", "restart", "networking"); err != nil {
+		return fmt.Errorf("networking service restart failed: %v", err)
 	}
 
 	return nil
@@ -330,32 +335,25 @@ func insertIntoIfaceBlock(lines []string, iface string, insertLines []string) []
 }
 
 func readInterfacesFile(path string) (string, error) {
-	cmd := exec.Command("cat", path)
-	output, err := cmd.CombinedOutput()
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return "", fmt.Errorf("failed to read interfaces file: %v", err)
 	}
-	return strings.TrimRight(string(output), "\n"), nil
+	return strings.TrimRight(string(data), "\n"), nil
 }
 
 func writeInterfacesFile(path, content string) error {
-	escaped := strings.ReplaceAll(content, `'`, `'\''`)
-	cmd := exec.Command("sh", "-c", fmt.Sprintf("echo '%s' | sudo tee %s > /dev/null", escaped, path))
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("failed to write interfaces file: %v, output: %s", err, string(output))
+	perm := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+	if err := writeFileAtomic(path, []byte(content+"\n"), perm); err != nil {
+		return fmt.Errorf("failed to write interfaces file: %v", err)
 	}
 	return nil
 }
 
 func applyNmcli(iface string, info client.InterfaceInfo) error {
-	// Check if interface exists before applying settings
-	cmd := exec.Command("ip", "link", "show", iface)
-	output, err := cmd.CombinedOutput()
-	if err != nil || strings.Contains(string(output), "Device does not exist") {
-		return fmt.Errorf("interface %s does not exist on this system", iface)
-	}
-
 	// IP アドレスとサブネットを CIDR 形式で連結
 	var addresses []string
 	for _, ip := range info.IPs {
@@ -367,41 +365,24 @@ func applyNmcli(iface string, info client.InterfaceInfo) error {
 	}
 
 	// DNS サーバをスライスに変換
-	var dnsList []string
-	for _, dns := range strings.Split(info.DnsServers, ",") {
-		trimmed := strings.TrimSpace(dns)
-		if trimmed != "" {
-			dnsList = append(dnsList, trimmed)
-		}
-	}
-
-	cmds := []string{
-		fmt.Sprintf("nmcli con mod %s ipv4.method manual ipv4.addresses \"%s\"", iface, strings.Join(addresses, ", ")),
-	}
+	dnsList := diff.SplitList(info.DnsServers)
 
+	args := []string{"con", "mod", iface, "ipv4.method", "manual", "ipv4.addresses", strings.Join(addresses, ", ")}
 	if info.Gateway != "" {
-		cmds = append(cmds, fmt.Sprintf("nmcli con mod %s ipv4.gateway \"%s\"", iface, info.Gateway))
-	}
-
-	for _, cmdStr := range cmds { // Use a string variable to avoid shadowing the exec.Command
-		output, err = exec.Command("sh", "-c", cmdStr).CombinedOutput()
-		if err != nil {
-			return fmt.Errorf("nmcli command failed with error: %v, output: %s", err, string(output))
-		}
+		args = append(args, "ipv4.gateway", info.Gateway)
 	}
-
 	if len(dnsList) > 0 {
-		cmd := fmt.Sprintf("nmcli con mod %s ipv4.dns \"%s\"", iface, strings.Join(dnsList, ", "))
-		output, err = exec.Command("sh", "-c", cmd).CombinedOutput()
-		if err != nil {
-			return fmt.Errorf("Setting DNS failed with error: %v, output: %s", err, string(output))
-		}
+		args = append(args, "ipv4.dns", strings.Join(dnsList, ", "))
+	}
+	if info.Mtu > 0 {
+		args = append(args, "802-3-ethernet.mtu", strconv.Itoa(info.Mtu))
+	}
+	if _, err := runCommand("nmcli", args...); err != nil {
+		return err
 	}
 
-	cmd = exec.Command("sh", "-c", "nmcli con up "+iface)
-	output, err = cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("nmcli connection up failed with error: %v, output: %s", err, string(output))
+	if _, err := runCommand("nmcli", "con", "up", iface); err != nil {
+		return fmt.Errorf("nmcli connection up failed: %v", err)
 	}
 	return nil
 }
@@ -410,12 +391,41 @@ func applyWindows(ifaces map[string]client.InterfaceInfo) error {
 	for name, info := range ifaces {
 		for _, ipInfo := range info.IPs {
 			args := []string{
-				"interface ip set address", fmt.Sprintf("name=\"%s\"", name), fmt.Sprintf("static %s %s", ipInfo.IP, ipInfo.Subnet),
+				"interface", "ip", "set", "address", "name=" + name, "static", ipInfo.IP, ipInfo.Subnet,
 			}
 			if info.Gateway != "" {
 				args = append(args, info.Gateway)
 			}
-			exec.Command("netsh", args...).Run()
+			if _, err := runCommand("netsh", args...); err != nil {
+				return fmt.Errorf("failed to apply settings for interface %s: %v", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateInterfaceSettings checks every value that ends up in a config file
+// or on a command line before anything is applied
+func validateInterfaceSettings(name string, info client.InterfaceInfo) error {
+	if err := validate.InterfaceName(name); err != nil {
+		return err
+	}
+	for _, ip := range info.IPs {
+		if _, err := validate.IPv4(ip.IP); err != nil {
+			return fmt.Errorf("invalid IP address %q: %v", ip.IP, err)
+		}
+		if _, err := subnetMaskToCIDR(ip.Subnet); err != nil {
+			return fmt.Errorf("invalid subnet mask %q: %v", ip.Subnet, err)
+		}
+	}
+	if info.Gateway != "" {
+		if _, err := validate.IPv4(info.Gateway); err != nil {
+			return fmt.Errorf("invalid gateway %q: %v", info.Gateway, err)
+		}
+	}
+	for _, dns := range diff.SplitList(info.DnsServers) {
+		if net.ParseIP(dns) == nil {
+			return fmt.Errorf("invalid DNS server address: %q", dns)
 		}
 	}
 	return nil
@@ -447,35 +457,12 @@ func GatherNetworkInterfaces() (map[string]client.InterfaceInfo, error) {
 		return nil, fmt.Errorf("failed to parse JSON: %v", err)
 	}
 
+	gateways, dns := interfaceRouting()
+
 	result := make(map[string]client.InterfaceInfo)
 	for _, ifaceData := range data {
 		name := ifaceData["ifname"].(string)
-
-		var macAddr string
-		macCmd := exec.Command("ip", "-o", "link")
-		macOutput, err := macCmd.CombinedOutput()
-		if err == nil {
-			lines := strings.Split(string(macOutput), "\n")
-			for _, line := range lines {
-				if strings.Contains(line, name) && strings.Contains(line, "link/ether") {
-					fields := strings.Fields(line)
-					for i, field := range fields {
-						if field == "link/ether" && i+1 < len(fields) {
-							macAddr = fields[i+1]
-							break
-						}
-					}
-				}
-			}
-
-			if macAddr == "" {
-				cmd := exec.Command("cat", "/sys/class/net/"+name+"/address")
-				output, err := cmd.CombinedOutput()
-				if err == nil && len(output) > 0 {
-					macAddr = strings.TrimSpace(string(output))
-				}
-			}
-		}
+		nic := inventory.ReadNIC(fsRoot, name)
 
 		var ipInfos []client.IPInfo
 		if addrs, ok := ifaceData["addr_info"].([]interface{}); ok && len(addrs) > 0 {
@@ -494,54 +481,39 @@ func GatherNetworkInterfaces() (map[string]client.InterfaceInfo, error) {
 
 		result[name] = client.InterfaceInfo{
 			IPs:        ipInfos,
-			Gateway:    "",
-			DnsServers: "", // Empty string for DNS servers, will be set to comma-separated values elsewhere if needed
-			MacAddress: macAddr,
+			Gateway:    gateways[name],
+			DnsServers: dns(name),
+			MacAddress: nic.MAC,
 			Name:       name, // Add the actual interface name
+			Mtu:        nic.MTU,
 		}
 	}
 
 	return result, nil
 }
 
-// GetMacAddress retrieves the MAC address for a given interface name using platform-specific commands
+// GetMacAddress retrieves the MAC address for a given interface name
 func GetMacAddress(iface string) (string, error) {
-	var cmd *exec.Cmd
-
-	switch runtime.GOOS {
-	case "linux":
-		cmd = exec.Command("sh", "-c", fmt.Sprintf(`cat $(find /sys/devices/ -name %s)/address`, iface))
-	case "windows":
-		cmd = exec.Command("wmic", "nic where \"NetConnectionID like '%"+iface+"%'", "get MACAddress /value")
-	default:
-		return "", fmt.Errorf("unsupported OS: %s", runtime.GOOS)
-	}
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return "", fmt.Errorf("command failed with error: %v, output: %s", err, string(output))
+	if err := validate.InterfaceName(iface); err != nil {
+		return "", err
 	}
 
 	var macAddr string
 	switch runtime.GOOS {
 	case "linux":
-		macAddr = strings.TrimSpace(string(output))
-		if macAddr == "" {
-			cmd = exec.Command("cat", "/sys/class/net/"+iface+"/address")
-			output, err = cmd.CombinedOutput()
-			if err == nil && len(output) > 0 {
-				macAddr = strings.TrimSpace(string(output))
-			}
+		data, err := os.ReadFile(filepath.Join("/sys/class/net", iface, "address"))
+		if err != nil {
+			return "", fmt.Errorf("failed to read MAC address: %v", err)
 		}
-
+		macAddr = strings.TrimSpace(string(data))
 	case "windows":
-		lines := strings.Split(string(output), "\n")
-		for _, line := range lines {
-			if strings.Contains(line, "MACAddress=") {
-				macAddr = strings.ReplaceAll(strings.TrimSpace(strings.Split(line, "=")[1]), "\"", "")
-				break
-			}
+		netIface, err := net.InterfaceByName(iface)
+		if err != nil {
+			return "", fmt.Errorf("failed to look up interface: %v", err)
 		}
+		macAddr = netIface.HardwareAddr.String()
+	default:
+		return "", fmt.Errorf("unsupported OS: %s", runtime.GOOS)
 	}
 
 	if macAddr == "" {
@@ -0,0 +1,45 @@
+package system
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	"boops/inventory"
+)
+
+// resolverTimeout bounds the resolver lookup the way the inventory registry
+// bounds each collector
+const resolverTimeout = 5 * time.Second
+
+// interfaceRouting returns the live IPv4 default gateway and the DNS servers
+// of each interface. Both are best effort: addresses are still useful
+// without them.
+func interfaceRouting() (gateways map[string]string, dns func(iface string) string) {
+	routes, _ := inventory.Routes(fsRoot)
+	gateways = inventory.DefaultGateways(routes, "inet")
+
+	// resolvectl hangs when systemd-resolved is wedged
+	ctx, cancel := context.WithTimeout(context.Background(), resolverTimeout)
+	defer cancel()
+	resolvers, err := inventory.DNSResolvers(ctx, fsRoot)
+	dns = func(iface string) string {
+		if err != nil {
+			return ""
+		}
+		// Without per-link servers, the global resolvers are only attributed
+		// to the interface carrying the default route
+		if len(resolvers.PerLink[iface]) == 0 && gateways[iface] == "" {
+			return ""
+		}
+		var servers []string
+		for _, s := range resolvers.For(iface) {
+			if ip := net.ParseIP(s); ip != nil && !ip.IsLoopback() {
+				servers = append(servers, s)
+			}
+		}
+		return strings.Join(servers, ",")
+	}
+	return gateways, dns
+}
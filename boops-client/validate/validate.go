@@ -0,0 +1,226 @@
+// Package validate checks the desired state returned by the BoopsDB API
+// before any of it is applied to the host.
+package validate
+
+import (
+	"fmt"
+	"net"
+	"runtime"
+	"strings"
+
+	"boops/client"
+	"boops/diff"
+)
+
+// Error describes a single problem with the desired state
+type Error struct {
+	Field   string `json:"field"`
+	Value   string `json:"value"`
+	Message string `json:"message"`
+}
+
+// Errors collects every problem found in a change set. A non-empty Errors
+// means the change set must be rejected as a whole.
+type Errors []Error
+
+func (e Errors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, fmt.Sprintf("%s: %s (%q)", err.Field, err.Message, err.Value))
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e *Errors) add(field, value, format string, args ...interface{}) {
+	*e = append(*e, Error{Field: field, Value: value, Message: fmt.Sprintf(format, args...)})
+}
+
+// Machine validates the hostname and network settings of m. It returns nil
+// or an Errors value listing every problem, never just the first one.
+func Machine(m client.Machine) error {
+	var errs Errors
+
+	if m.Hostname != "" {
+		if err := Hostname(m.Hostname); err != nil {
+			errs.add("hostname", m.Hostname, "%v", err)
+		}
+	}
+
+	var subnets []*net.IPNet
+	names := make(map[string]bool)
+	owners := make(map[string]string) // IP -> interface that claims it
+
+	for i, iface := range m.Interfaces {
+		prefix := fmt.Sprintf("interfaces[%d]", i)
+		if err := InterfaceName(iface.Name); err != nil {
+			errs.add(prefix+".name", iface.Name, "%v", err)
+		} else {
+			prefix = fmt.Sprintf("interfaces[%s]", iface.Name)
+		}
+		if names[iface.Name] {
+			errs.add(prefix+".name", iface.Name, "duplicate interface name")
+		}
+		names[iface.Name] = true
+
+		for j, ipInfo := range iface.IPs {
+			field := fmt.Sprintf("%s.ips[%d]", prefix, j)
+			ip, err := IPv4(ipInfo.IP)
+			if err != nil {
+				errs.add(field+".ip_address", ipInfo.IP, "%v", err)
+				continue
+			}
+			mask, err := SubnetMask(ipInfo.Subnet)
+			if err != nil {
+				errs.add(field+".subnet_mask", ipInfo.Subnet, "%v", err)
+				continue
+			}
+			if owner, ok := owners[ip.String()]; ok {
+				errs.add(field+".ip_address", ipInfo.IP, "duplicate IP address, already assigned to %s", owner)
+				continue
+			}
+			owners[ip.String()] = iface.Name
+			subnets = append(subnets, &net.IPNet{IP: ip.Mask(mask), Mask: mask})
+		}
+
+		for _, dns := range diff.SplitList(iface.DnsServers) {
+			if net.ParseIP(dns) == nil {
+				errs.add(prefix+".dns_servers", dns, "invalid DNS server address")
+			}
+		}
+	}
+
+	// Gateways are checked last so every configured subnet is known
+	for i, iface := range m.Interfaces {
+		if iface.Gateway == "" || iface.Gateway == "0.0.0.0" {
+			continue
+		}
+		field := fmt.Sprintf("interfaces[%d].gateway", i)
+		if InterfaceName(iface.Name) == nil {
+			field = fmt.Sprintf("interfaces[%s].gateway", iface.Name)
+		}
+		gw, err := IPv4(iface.Gateway)
+		if err != nil {
+			errs.add(field, iface.Gateway, "%v", err)
+			continue
+		}
+		reachable := false
+		for _, s := range subnets {
+			if s.Contains(gw) {
+				reachable = true
+				break
+			}
+		}
+		if !reachable {
+			errs.add(field, iface.Gateway, "gateway is not inside any configured subnet")
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Hostname checks name against RFC 1123: at most 253 characters, dot
+// separated labels of 1-63 letters, digits and hyphens that neither start nor
+// end with a hyphen
+func Hostname(name string) error {
+	name = strings.TrimSuffix(name, ".")
+	if name == "" {
+		return fmt.Errorf("hostname is empty")
+	}
+	if len(name) > 253 {
+		return fmt.Errorf("hostname is longer than 253 characters")
+	}
+	for _, label := range strings.Split(name, ".") {
+		if label == "" {
+			return fmt.Errorf("hostname has an empty label")
+		}
+		if len(label) > 63 {
+			return fmt.Errorf("hostname label %q is longer than 63 characters", label)
+		}
+		for _, r := range label {
+			isAlnum := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+			if !isAlnum && r != '-' {
+				return fmt.Errorf("hostname contains invalid character %q", r)
+			}
+		}
+		if label[0] == '-' || label[len(label)-1] == '-' {
+			return fmt.Errorf("hostname label %q starts or ends with '-'", label)
+		}
+	}
+	return nil
+}
+
+// InterfaceName rejects names that could not be a real interface on the
+// running OS or that could be mistaken for a command line option or a path
+func InterfaceName(name string) error {
+	if name == "" {
+		return fmt.Errorf("interface name is empty")
+	}
+	if strings.HasPrefix(name, "-") {
+		return fmt.Errorf("interface name starts with '-'")
+	}
+	for _, r := range name {
+		if r < 0x20 || r == 0x7f || r == '"' || r == '\'' || r == '%' {
+			return fmt.Errorf("interface name contains invalid character %q", r)
+		}
+	}
+
+	if runtime.GOOS == "windows" {
+		// Windows connection names may contain spaces, e.g. "Ethernet 2"
+		if len(name) > 256 {
+			return fmt.Errorf("interface name is too long")
+		}
+		return nil
+	}
+
+	// Linux limits names to IFNAMSIZ-1 bytes and forbids '/' and whitespace
+	if len(name) > 15 {
+		return fmt.Errorf("interface name is longer than 15 characters")
+	}
+	if name == "." || name == ".." || strings.ContainsAny(name, "/: \t") {
+		return fmt.Errorf("interface name is not a valid Linux interface name")
+	}
+	return nil
+}
+
+// IPv4 parses a dotted-quad IPv4 address
+func IPv4(addr string) (net.IP, error) {
+	ip := net.ParseIP(addr)
+	if ip == nil || ip.To4() == nil || strings.Contains(addr, ":") {
+		return nil, fmt.Errorf("invalid IPv4 address")
+	}
+	return ip.To4(), nil
+}
+
+// SubnetMask parses a dotted-quad netmask and rejects non-contiguous masks
+func SubnetMask(mask string) (net.IPMask, error) {
+	ip, err := IPv4(mask)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subnet mask")
+	}
+	m := net.IPMask(ip)
+	if ones, bits := m.Size(); bits == 0 || ones == 0 {
+		return nil, fmt.Errorf("invalid subnet mask")
+	}
+	return m, nil
+}
+
+// Report is the validation result uploaded to the server after each sync
+type Report struct {
+	Valid  bool   `json:"valid"`
+	Errors Errors `json:"errors,omitempty"`
+}
+
+// NewReport builds the report for the error returned by Machine
+func NewReport(err error) Report {
+	if err == nil {
+		return Report{Valid: true}
+	}
+	errs, ok := err.(Errors)
+	if !ok {
+		errs = Errors{{Message: err.Error()}}
+	}
+	return Report{Valid: false, Errors: errs}
+}
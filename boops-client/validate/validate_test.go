@@ -0,0 +1,229 @@
+package validate
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"boops/client"
+)
+
+func TestHostname(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{"db", false},
+		{"db01.example.com", false},
+		{"db01.example.com.", false},
+		{"1db", false},
+		{"a-b.c-d", false},
+		{strings.Repeat("a", 63) + ".example.com", false},
+		{strings.Repeat("a.", 126) + "a", false},
+		{"", true},
+		{".", true},
+		{strings.Repeat("a", 64) + ".example.com", true},
+		{strings.Repeat("a.", 127) + "a", true},
+		{"-db", true},
+		{"db-", true},
+		{"db.-example.com", true},
+		{"db..example.com", true},
+		{".db", true},
+		{"db_01", true},
+		{"db 01", true},
+		{"db/01", true},
+		{"db;reboot", true},
+		{"dé", true},
+	}
+	for _, tt := range tests {
+		if err := Hostname(tt.name); (err != nil) != tt.wantErr {
+			t.Errorf("Hostname(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestInterfaceName(t *testing.T) {
+	tests := []struct {
+		name      string
+		wantErr   bool
+		linuxOnly bool
+	}{
+		{name: "eth0"},
+		{name: "enp3s0f1"},
+		{name: "bond0.100"},
+		{name: "", wantErr: true},
+		{name: "-eth0", wantErr: true},
+		{name: "--help", wantErr: true},
+		{name: "eth0'", wantErr: true},
+		{name: "eth%d", wantErr: true},
+		{name: "eth\n0", wantErr: true},
+		{name: "../eth0", wantErr: true, linuxOnly: true},
+		{name: "eth/0", wantErr: true, linuxOnly: true},
+		{name: "eth 0", wantErr: true, linuxOnly: true},
+		{name: "eth0:1", wantErr: true, linuxOnly: true},
+		{name: ".", wantErr: true, linuxOnly: true},
+		{name: "abcdefghijklmnop", wantErr: true, linuxOnly: true},
+	}
+	for _, tt := range tests {
+		if tt.linuxOnly && runtime.GOOS == "windows" {
+			continue
+		}
+		if err := InterfaceName(tt.name); (err != nil) != tt.wantErr {
+			t.Errorf("InterfaceName(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSubnetMask(t *testing.T) {
+	tests := []struct {
+		mask     string
+		wantOnes int
+		wantErr  bool
+	}{
+		{mask: "255.255.255.0", wantOnes: 24},
+		{mask: "255.255.255.255", wantOnes: 32},
+		{mask: "128.0.0.0", wantOnes: 1},
+		{mask: "0.0.0.0", wantErr: true},
+		{mask: "255.0.255.0", wantErr: true},
+		{mask: "255.255.255.1", wantErr: true},
+		{mask: "24", wantErr: true},
+		{mask: "ffff:ffff::", wantErr: true},
+		{mask: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := SubnetMask(tt.mask)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("SubnetMask(%q) error = %v, wantErr %v", tt.mask, err, tt.wantErr)
+			continue
+		}
+		if err == nil {
+			if ones, _ := got.Size(); ones != tt.wantOnes {
+				t.Errorf("SubnetMask(%q) = /%d, want /%d", tt.mask, ones, tt.wantOnes)
+			}
+		}
+	}
+}
+
+func TestIPv4(t *testing.T) {
+	for addr, wantErr := range map[string]bool{
+		"10.0.0.5":         false,
+		"0.0.0.0":          false,
+		"10.0.0":           true,
+		"10.0.0.256":       true,
+		"::1":              true,
+		"::ffff:10.0.0.5":  true,
+		" 10.0.0.5":        true,
+		"10.0.0.5/24":      true,
+		"":                 true,
+		"example.com":      true,
+		"10.0.0.5;reboot":  true,
+		"010.000.000.005x": true,
+	} {
+		if _, err := IPv4(addr); (err != nil) != wantErr {
+			t.Errorf("IPv4(%q) error = %v, wantErr %v", addr, err, wantErr)
+		}
+	}
+}
+
+func TestMachine(t *testing.T) {
+	eth0 := client.InterfaceInfo{
+		Name:       "eth0",
+		IPs:        []client.IPInfo{{IP: "10.0.0.5", Subnet: "255.255.255.0"}},
+		Gateway:    "10.0.0.1",
+		DnsServers: " 10.0.0.53 ,10.0.0.54,",
+	}
+	tests := []struct {
+		name       string
+		machine    client.Machine
+		wantFields []string
+	}{
+		{
+			name:    "valid",
+			machine: client.Machine{Hostname: "db01.example.com", Interfaces: []client.InterfaceInfo{eth0}},
+		},
+		{
+			name: "no gateway",
+			machine: client.Machine{Interfaces: []client.InterfaceInfo{
+				{Name: "eth0", IPs: eth0.IPs},
+				{Name: "eth1", IPs: []client.IPInfo{{IP: "192.168.0.5", Subnet: "255.255.255.0"}}, Gateway: "0.0.0.0"},
+			}},
+		},
+		{
+			name: "gateway reachable through another interface",
+			machine: client.Machine{Interfaces: []client.InterfaceInfo{
+				{Name: "eth0", IPs: eth0.IPs},
+				{Name: "eth1", Gateway: "10.0.0.1"},
+			}},
+		},
+		{
+			name: "gateway outside every subnet",
+			machine: client.Machine{Interfaces: []client.InterfaceInfo{
+				{Name: "eth0", IPs: eth0.IPs, Gateway: "10.0.1.1"},
+			}},
+			wantFields: []string{"interfaces[eth0].gateway"},
+		},
+		{
+			name: "non-contiguous and /0 masks",
+			machine: client.Machine{Interfaces: []client.InterfaceInfo{
+				{Name: "eth0", IPs: []client.IPInfo{
+					{IP: "10.0.0.5", Subnet: "255.0.255.0"},
+					{IP: "10.0.0.6", Subnet: "0.0.0.0"},
+				}},
+			}},
+			wantFields: []string{"interfaces[eth0].ips[0].subnet_mask", "interfaces[eth0].ips[1].subnet_mask"},
+		},
+		{
+			name: "duplicate IPs and names",
+			machine: client.Machine{Interfaces: []client.InterfaceInfo{
+				eth0,
+				{Name: "eth0", IPs: []client.IPInfo{{IP: "10.0.0.6", Subnet: "255.255.255.0"}}},
+				{Name: "eth1", IPs: []client.IPInfo{{IP: "10.0.0.5", Subnet: "255.255.255.0"}}},
+			}},
+			wantFields: []string{"interfaces[eth0].name", "interfaces[eth1].ips[0].ip_address"},
+		},
+		{
+			name: "every problem is reported",
+			machine: client.Machine{Hostname: "-db", Interfaces: []client.InterfaceInfo{
+				{Name: "-eth0", IPs: []client.IPInfo{{IP: "10.0.0.300", Subnet: "255.255.255.0"}}, DnsServers: "10.0.0.53,dns"},
+				{Name: "eth/1", Gateway: "gw"},
+			}},
+			wantFields: []string{
+				"hostname",
+				"interfaces[0].name",
+				"interfaces[0].ips[0].ip_address",
+				"interfaces[0].dns_servers",
+				"interfaces[1].name",
+				"interfaces[1].gateway",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Machine(tt.machine)
+			var fields []string
+			if err != nil {
+				errs, ok := err.(Errors)
+				if !ok {
+					t.Fatalf("Machine() error = %T, want Errors", err)
+				}
+				for _, e := range errs {
+					fields = append(fields, e.Field)
+				}
+			}
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("Machine() error fields = %q, want %q (%v)", fields, tt.wantFields, err)
+			}
+		})
+	}
+}
+
+func TestNewReport(t *testing.T) {
+	if got := NewReport(nil); !got.Valid || got.Errors != nil {
+		t.Errorf("NewReport(nil) = %+v, want valid", got)
+	}
+	err := Machine(client.Machine{Hostname: "-"})
+	if got := NewReport(err); got.Valid || len(got.Errors) != 1 || got.Errors[0].Field != "hostname" {
+		t.Errorf("NewReport(%v) = %+v, want one hostname error", err, got)
+	}
+}
@@ -24,6 +24,11 @@ process.on('unhandledRejection', (reason, promise) => {
 
 const port = 3001;
 
+// model_info and disk_info are stored as TEXT; structured values sent by the
+// agent (hardware identity, disk list) are kept as their JSON encoding
+const serializeStructured = (value) =>
+  value !== null && typeof value === 'object' ? JSON.stringify(value) : value;
+
 // GET all machines with interfaces
 app.get('/api/machines', async (req, res) => {
   try {
@@ -32,7 +37,7 @@ app.get('/api/machines', async (req, res) => {
 
     for (const machine of machines) {
       const [interfaces] = await db.query(
-        'SELECT id, name, gateway, dns_servers, mac_address FROM interfaces WHERE machine_id = ?',
+        'SELECT id, name, gateway, dns_servers, mac_address, mtu FROM interfaces WHERE machine_id = ?',
         [machine.id]
       );
 
@@ -42,6 +47,7 @@ app.get('/api/machines', async (req, res) => {
           [iface.id]
         );
         iface.ips = ips;
+        iface.lldp_neighbors = await interfaceNeighbors(machine.id, iface.name);
       }
 
       results.push({ ...machine, interfaces });
@@ -65,13 +71,13 @@ app.post('/api/machines', async (req, res) => {
 
     await conn.query(
       'INSERT INTO machines (id, hostname, model_info, usage_desc, memo, purpose, last_alive, cpu_info, cpu_arch, memory_size, disk_info, os_name, is_virtual, parent_machine_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)',
-      [machineId, hostname, model_info, usage_desc, memo, purpose || '', last_alive, cpu_info || '', cpu_arch || '', memory_size || '', disk_info || '', os_name || '', is_virtual === true, parent_machine_id || null]
+      [machineId, hostname, serializeStructured(model_info), usage_desc, memo, purpose || '', last_alive, cpu_info || '', cpu_arch || '', memory_size || '', serializeStructured(disk_info) || '', os_name || '', is_virtual === true, parent_machine_id || null]
     );
 
-    for (const [name, { ips, gateway, dns_servers, mac_address }] of Object.entries(interfaces)) {
+    for (const [name, { ips, gateway, dns_servers, mac_address, mtu }] of Object.entries(interfaces)) {
       await conn.query(
-        'INSERT INTO interfaces (machine_id, name, gateway, dns_servers, mac_address) VALUES (?, ?, ?, ?, ?)',
-        [machineId, name, gateway || '', Array.isArray(dns_servers) ? dns_servers.join(',') : '', mac_address || '']
+        'INSERT INTO interfaces (machine_id, name, gateway, dns_servers, mac_address, mtu) VALUES (?, ?, ?, ?, ?, ?)',
+        [machineId, name, gateway || '', Array.isArray(dns_servers) ? dns_servers.join(',') : '', mac_address || '', mtuValue(mtu)]
       );
 
       const [interfaceResult] = await conn.query(
@@ -101,10 +107,152 @@ app.post('/api/machines', async (req, res) => {
   }
 });
 
+// The agent sends interfaces as a list of named entries and DNS servers as a
+// comma-separated string; the UI uses an object keyed by name and arrays
+const interfaceEntries = (interfaces) =>
+  Array.isArray(interfaces)
+    ? interfaces.filter((iface) => iface && iface.name).map((iface) => [iface.name, iface])
+    : Object.entries(interfaces || {});
+
+const dnsServersValue = (dnsServers) =>
+  Array.isArray(dnsServers) ? dnsServers.join(',') : typeof dnsServers === 'string' ? dnsServers : '';
+
+// An unset MTU is stored as NULL and left alone by the agent
+const mtuValue = (mtu) => {
+  const value = Number(mtu);
+  return Number.isInteger(value) && value > 0 ? value : null;
+};
+
+// LLDP neighbors are what the agent sees on the wire, not desired state, so
+// they are kept by interface name and survive the interface rows being
+// rewritten when an operator edits the machine
+const replaceNeighbors = async (conn, machineId, interfaceName, neighbors) => {
+  await conn.query('DELETE FROM interface_lldp_neighbors WHERE machine_id = ? AND interface_name = ?', [machineId, interfaceName]);
+  for (const n of neighbors) {
+    await conn.query(
+      'INSERT INTO interface_lldp_neighbors (machine_id, interface_name, chassis_id, system_name, port_id, port_description, management_address, vlan_id, vlan_name) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)',
+      [machineId, interfaceName, n.chassis_id || '', n.system_name || '', n.port_id || '', n.port_description || '', n.management_address || '', n.vlan_id || null, n.vlan_name || '']
+    );
+  }
+};
+
+const validNeighbors = (neighbors) =>
+  Array.isArray(neighbors) &&
+  neighbors.every((n) => n && typeof n === 'object' && (typeof n.chassis_id === 'string' || typeof n.port_id === 'string'));
+
+const interfaceNeighbors = async (machineId, interfaceName) => {
+  const [neighbors] = await db.query(
+    'SELECT chassis_id, system_name, port_id, port_description, management_address, vlan_id, vlan_name, updated_at FROM interface_lldp_neighbors WHERE machine_id = ? AND interface_name = ? ORDER BY id',
+    [machineId, interfaceName]
+  );
+  return neighbors;
+};
+
+// Host keys are replaced as a set, so keys removed from the machine (e.g.
+// after re-provisioning) stop being trusted
+const replaceHostKeys = async (conn, machineId, hostKeys) => {
+  await conn.query('DELETE FROM machine_ssh_host_keys WHERE machine_id = ?', [machineId]);
+  for (const { type, public_key, fingerprint } of hostKeys) {
+    await conn.query(
+      'INSERT INTO machine_ssh_host_keys (machine_id, key_type, public_key, fingerprint) VALUES (?, ?, ?, ?)',
+      [machineId, type, public_key, fingerprint]
+    );
+  }
+};
+
+const validHostKeys = (hostKeys) =>
+  Array.isArray(hostKeys) &&
+  hostKeys.every((key) =>
+    key && typeof key.type === 'string' && typeof key.fingerprint === 'string' &&
+    typeof key.public_key === 'string' && key.public_key.startsWith(`${key.type} `));
+
+// POST register a machine from the agent (boops regist <id>)
+// Inventory fields are overwritten; interfaces already on record are kept so
+// the desired network state edited by operators is never replaced
+app.post('/api/machines/:id', async (req, res) => {
+  const machineId = req.params.id;
+  const { hostname, model_info, cpu_info, cpu_arch, memory_size, disk_info, os_name, is_virtual, interfaces, ssh_host_keys } = req.body;
+
+  // Validate UUID format for machine ID
+  if (!/^[0-9a-fA-F]{8}\b-[0-9a-fA-F]{4}\b-[0-9a-fA-F]{4}\b-[0-9a-fA-F]{4}\b-[0-9a-fA-F]{12}$/.test(machineId)) {
+    return res.status(400).json({ error: 'Invalid machine UUID format' });
+  }
+  if (ssh_host_keys !== undefined && !validHostKeys(ssh_host_keys)) {
+    return res.status(400).json({ error: 'SSH host keys must be a list of { type, public_key, fingerprint }' });
+  }
+  if (interfaceEntries(interfaces).some(([, iface]) => iface.lldp_neighbors !== undefined && !validNeighbors(iface.lldp_neighbors))) {
+    return res.status(400).json({ error: 'LLDP neighbors must be a list of { chassis_id, port_id, ... }' });
+  }
+
+  const conn = await db.getConnection();
+  try {
+    await conn.beginTransaction();
+
+    const [existing] = await conn.query('SELECT id FROM machines WHERE id = ?', [machineId]);
+    if (existing.length === 0) {
+      await conn.query('INSERT INTO machines (id, hostname, purpose) VALUES (?, ?, ?)', [machineId, hostname || '', '']);
+    }
+
+    await conn.query(
+      'UPDATE machines SET hostname=?, model_info=?, cpu_info=?, cpu_arch=?, memory_size=?, disk_info=?, os_name=?, is_virtual=? WHERE id=?',
+      [hostname || '', serializeStructured(model_info), cpu_info || '', cpu_arch || '', memory_size || '', serializeStructured(disk_info) || '', os_name || '', is_virtual === true || is_virtual === 1, machineId]
+    );
+
+    if (ssh_host_keys !== undefined) {
+      await replaceHostKeys(conn, machineId, ssh_host_keys);
+    }
+
+    const registered = [];
+    for (const [name, { ips, gateway, dns_servers, mac_address, mtu, lldp_neighbors }] of interfaceEntries(interfaces)) {
+      if (lldp_neighbors !== undefined) {
+        await replaceNeighbors(conn, machineId, name, lldp_neighbors);
+      }
+
+      const [existingInterface] = await conn.query(
+        'SELECT id FROM interfaces WHERE machine_id = ? AND name = ?',
+        [machineId, name]
+      );
+      if (existingInterface.length > 0) {
+        continue;
+      }
+
+      await conn.query(
+        'INSERT INTO interfaces (machine_id, name, gateway, dns_servers, mac_address, mtu) VALUES (?, ?, ?, ?, ?, ?)',
+        [machineId, name, gateway || '', dnsServersValue(dns_servers), mac_address || '', mtuValue(mtu)]
+      );
+
+      const [interfaceResult] = await conn.query(
+        'SELECT id FROM interfaces WHERE machine_id = ? AND name = ? ORDER BY id DESC LIMIT 1',
+        [machineId, name]
+      );
+      const interfaceId = interfaceResult[0].id;
+
+      for (const { ip_address: ip, subnet_mask: subnet, dns_register } of ips || []) {
+        if (!ip) {
+          throw new Error(`IP address cannot be null for interface ${name}`);
+        }
+        await conn.query(
+          'INSERT INTO interface_ips (interface_id, ip_address, subnet_mask, dns_register) VALUES (?, ?, ?, ?)',
+          [interfaceId, ip, subnet || '', !!dns_register]
+        );
+      }
+      registered.push(name);
+    }
+
+    await conn.commit();
+    res.json({ message: 'Registered', id: machineId, interfaces: registered });
+  } catch (err) {
+    await conn.rollback();
+    res.status(500).json({ error: err.message });
+  } finally {
+    conn.release();
+  }
+});
+
 // POST add new interface to a machine
 app.post('/api/machines/:id/interfaces', async (req, res) => {
   const machineId = req.params.id;
-  const { name, ips, gateway, dns_servers, mac_address } = req.body;
+  const { name, ips, gateway, dns_servers, mac_address, mtu } = req.body;
 
   // Validate UUID format for machine ID
   if (!/^[0-9a-fA-F]{8}\b-[0-9a-fA-F]{4}\b-[0-9a-fA-F]{4}\b-[0-9a-fA-F]{4}\b-[0-9a-fA-F]{12}$/.test(machineId)) {
@@ -134,13 +282,14 @@ app.post('/api/machines/:id/interfaces', async (req, res) => {
 
     // Insert new interface
     await db.query(
-      'INSERT INTO interfaces (machine_id, name, gateway, dns_servers, mac_address) VALUES (?, ?, ?, ?, ?)',
+      'INSERT INTO interfaces (machine_id, name, gateway, dns_servers, mac_address, mtu) VALUES (?, ?, ?, ?, ?, ?)',
       [
         machineId,
         name,
         gateway || '',
         Array.isArray(dns_servers) ? dns_servers.join(',') : '',
-        mac_address || ''
+        mac_address || '',
+        mtuValue(mtu)
       ]
     );
 
@@ -200,6 +349,10 @@ app.delete('/api/machines/:machineId/interfaces/:interfaceName', async (req, res
       'DELETE FROM interfaces WHERE machine_id = ? AND name = ?',
       [machineId, interfaceName]
     );
+    await conn.query(
+      'DELETE FROM interface_lldp_neighbors WHERE machine_id = ? AND interface_name = ?',
+      [machineId, interfaceName]
+    );
 
     await conn.commit();
     res.json({ message: 'Interface deleted successfully' });
@@ -311,20 +464,20 @@ app.put('/api/machines/:id', async (req, res) => {
 
     await conn.query(
       'UPDATE machines SET hostname=?, model_info=?, usage_desc=?, memo=?, purpose=?, last_alive=?, cpu_info=?, cpu_arch=?, memory_size=?, disk_info=?, os_name=?, is_virtual=?, parent_machine_id=? WHERE id=?',
-      [hostname, model_info, usage_desc, memo, purpose || '', last_alive, cpu_info || '', cpu_arch || '', memory_size || '', disk_info || '', os_name || '', is_virtual === true, parent_machine_id || null, machineId]
+      [hostname, serializeStructured(model_info), usage_desc, memo, purpose || '', last_alive, cpu_info || '', cpu_arch || '', memory_size || '', serializeStructured(disk_info) || '', os_name || '', is_virtual === true, parent_machine_id || null, machineId]
     );
 
     await conn.query('DELETE FROM interfaces WHERE machine_id = ?', [machineId]);
     await conn.query('DELETE FROM interface_ips WHERE interface_id IN (SELECT id FROM interfaces WHERE machine_id = ?)', [machineId]);
 
-    for (const [name, { ips, gateway, dns_servers, mac_address }] of Object.entries(interfaces)) {
+    for (const [name, { ips, gateway, dns_servers, mac_address, mtu }] of Object.entries(interfaces)) {
       if (!ips || ips.length === 0) {
         return res.status(400).json({ error: `At least one IP address is required for interface ${name}` });
       }
 
       await conn.query(
-        'INSERT INTO interfaces (machine_id, name, gateway, dns_servers, mac_address) VALUES (?, ?, ?, ?, ?)',
-        [machineId, name, gateway || '', Array.isArray(dns_servers) ? dns_servers.join(',') : '', mac_address || '']
+        'INSERT INTO interfaces (machine_id, name, gateway, dns_servers, mac_address, mtu) VALUES (?, ?, ?, ?, ?, ?)',
+        [machineId, name, gateway || '', Array.isArray(dns_servers) ? dns_servers.join(',') : '', mac_address || '', mtuValue(mtu)]
       );
 
       const [interfaceResult] = await conn.query(
@@ -470,6 +623,41 @@ app.put('/api/interfaces/:machineId/:interfaceName/update-gateway', async (req,
   }
 });
 
+// PUT update the MTU of an interface; null or empty clears it
+app.put('/api/interfaces/:machineId/:interfaceName/update-mtu', async (req, res) => {
+  const machineId = req.params.machineId;
+  const interfaceName = req.params.interfaceName;
+  const { mtu } = req.body;
+
+  // Validate UUID format for machine ID
+  if (!/^[0-9a-fA-F]{8}\b-[0-9a-fA-F]{4}\b-[0-9a-fA-F]{4}\b-[0-9a-fA-F]{4}\b-[0-9a-fA-F]{12}$/.test(machineId)) {
+    return res.status(400).json({ error: 'Invalid machine UUID format' });
+  }
+
+  // Validate MTU range (68 is the IPv4 minimum, 65535 the largest jumbo frame)
+  const value = mtuValue(mtu);
+  if (mtu !== null && mtu !== undefined && mtu !== '' && (value === null || value < 68 || value > 65535)) {
+    return res.status(400).json({ error: 'MTU must be an integer between 68 and 65535' });
+  }
+
+  try {
+    await db.query(
+      'UPDATE interfaces SET mtu = ? WHERE machine_id = ? AND name = ?',
+      [value, machineId, interfaceName]
+    );
+
+    // Check if any rows were affected
+    const [result] = await db.query('SELECT ROW_COUNT() AS count');
+    if (result[0].count > 0) {
+      res.json({ message: 'MTU updated' });
+    } else {
+      res.status(404).json({ error: 'Interface not found for this machine' });
+    }
+  } catch (err) {
+    res.status(500).json({ error: err.message });
+  }
+});
+
 // PUT update DNS servers for a specific interface
 app.put('/api/interfaces/:machineId/:interfaceName/update-dns', async (req, res) => {
   const machineId = req.params.machineId;
@@ -825,7 +1013,7 @@ app.get('/api/machines/search', async (req, res) => {
     
     for (const machine of machines) {
       const [interfaces] = await db.query(
-        'SELECT id, name, gateway, dns_servers, mac_address FROM interfaces WHERE machine_id = ?',
+        'SELECT id, name, gateway, dns_servers, mac_address, mtu FROM interfaces WHERE machine_id = ?',
         [machine.id]
       );
     
@@ -835,6 +1023,7 @@ app.get('/api/machines/search', async (req, res) => {
           [iface.id]
         );
         iface.ips = ips;
+        iface.lldp_neighbors = await interfaceNeighbors(machine.id, iface.name);
       }
     
       results.push({ ...machine, interfaces });
@@ -1085,7 +1274,7 @@ app.get('/api/machines/:uuid', async (req, res) => {
 
     // Get interfaces for the machine
     const [interfaces] = await db.query(
-      'SELECT id, name, gateway, dns_servers, mac_address FROM interfaces WHERE machine_id = ?',
+      'SELECT id, name, gateway, dns_servers, mac_address, mtu FROM interfaces WHERE machine_id = ?',
       [machine.id]
     );
 
@@ -1095,6 +1284,7 @@ app.get('/api/machines/:uuid', async (req, res) => {
         [iface.id]
       );
       iface.ips = ips;
+      iface.lldp_neighbors = await interfaceNeighbors(machine.id, iface.name);
     }
 
     const result = { ...machine, interfaces };
@@ -1219,6 +1409,71 @@ app.put('/api/machines/:id/update-memory_size', async (req, res) => {
   }
 });
 
+// PUT update model info (DMI/SMBIOS hardware identity) for a specific machine
+app.put('/api/machines/:id/update-model_info', async (req, res) => {
+  const machineId = req.params.id;
+  const { model_info } = req.body;
+
+  // Validate UUID format for machine ID
+  if (!/^[0-9a-fA-F]{8}\b-[0-9a-fA-F]{4}\b-[0-9a-fA-F]{4}\b-[0-9a-fA-F]{4}\b-[0-9a-fA-F]{12}$/.test(machineId)) {
+    return res.status(400).json({ error: 'Invalid machine UUID format' });
+  }
+
+  // Validate that model_info is an object or a non-empty string
+  const isObject = model_info !== null && typeof model_info === 'object' && !Array.isArray(model_info);
+  if (!isObject && (typeof model_info !== 'string' || !model_info.trim())) {
+    return res.status(400).json({ error: 'Model info must be an object or a non-empty string' });
+  }
+
+  try {
+    await db.query('UPDATE machines SET model_info = ? WHERE id = ?', [serializeStructured(model_info), machineId]);
+
+    // Check if any rows were affected
+    const [result] = await db.query('SELECT ROW_COUNT() AS count');
+    if (result[0].count > 0) {
+      res.json({ message: 'Model info updated' });
+    } else {
+      res.status(404).json({ error: 'Machine not found' });
+    }
+  } catch (err) {
+    res.status(500).json({ error: err.message });
+  }
+});
+
+// PUT update the SSH host keys reported by the agent
+app.put('/api/machines/:id/update-ssh_host_keys', async (req, res) => {
+  const machineId = req.params.id;
+  const { ssh_host_keys } = req.body;
+
+  // Validate UUID format for machine ID
+  if (!/^[0-9a-fA-F]{8}\b-[0-9a-fA-F]{4}\b-[0-9a-fA-F]{4}\b-[0-9a-fA-F]{4}\b-[0-9a-fA-F]{12}$/.test(machineId)) {
+    return res.status(400).json({ error: 'Invalid machine UUID format' });
+  }
+  if (!validHostKeys(ssh_host_keys)) {
+    return res.status(400).json({ error: 'SSH host keys must be a list of { type, public_key, fingerprint }' });
+  }
+
+  const conn = await db.getConnection();
+  try {
+    await conn.beginTransaction();
+
+    const [machines] = await conn.query('SELECT id FROM machines WHERE id = ?', [machineId]);
+    if (machines.length === 0) {
+      await conn.rollback();
+      return res.status(404).json({ error: 'Machine not found' });
+    }
+    await replaceHostKeys(conn, machineId, ssh_host_keys);
+
+    await conn.commit();
+    res.json({ message: 'SSH host keys updated' });
+  } catch (err) {
+    await conn.rollback();
+    res.status(500).json({ error: err.message });
+  } finally {
+    conn.release();
+  }
+});
+
 // PUT update CPU architecture for a specific machine
 app.put('/api/machines/:id/update-cpu_arch', async (req, res) => {
   const machineId = req.params.id;
@@ -1259,13 +1514,13 @@ app.put('/api/machines/:id/update-disk_info', async (req, res) => {
     return res.status(400).json({ error: 'Invalid machine UUID format' });
   }
 
-  // Validate that disk_info is provided
-  if (typeof disk_info !== 'string' || !disk_info.trim()) {
-    return res.status(400).json({ error: 'Disk info must be a non-empty string' });
+  // Validate that disk_info is a disk list or a non-empty string
+  if (!Array.isArray(disk_info) && (typeof disk_info !== 'string' || !disk_info.trim())) {
+    return res.status(400).json({ error: 'Disk info must be an array of disks or a non-empty string' });
   }
 
   try {
-    await db.query('UPDATE machines SET disk_info = ? WHERE id = ?', [disk_info, machineId]);
+    await db.query('UPDATE machines SET disk_info = ? WHERE id = ?', [serializeStructured(disk_info), machineId]);
 
     // Check if any rows were affected
     const [result] = await db.query('SELECT ROW_COUNT() AS count');
@@ -1343,6 +1598,257 @@ app.put('/api/machines/:machineId/interfaces/:interfaceName/update-mac_address',
   }
 });
 
+// PUT replace the LLDP neighbors the agent sees on an interface
+app.put('/api/machines/:machineId/interfaces/:interfaceName/update-lldp_neighbors', async (req, res) => {
+  const machineId = req.params.machineId;
+  const interfaceName = req.params.interfaceName;
+  const { lldp_neighbors } = req.body;
+
+  // Validate UUID format for machine ID
+  if (!/^[0-9a-fA-F]{8}\b-[0-9a-fA-F]{4}\b-[0-9a-fA-F]{4}\b-[0-9a-fA-F]{4}\b-[0-9a-fA-F]{12}$/.test(machineId)) {
+    return res.status(400).json({ error: 'Invalid machine UUID format' });
+  }
+  if (!validNeighbors(lldp_neighbors)) {
+    return res.status(400).json({ error: 'LLDP neighbors must be a list of { chassis_id, port_id, ... }' });
+  }
+
+  const conn = await db.getConnection();
+  try {
+    await conn.beginTransaction();
+
+    const [existingInterface] = await conn.query(
+      'SELECT id FROM interfaces WHERE machine_id = ? AND name = ?',
+      [machineId, interfaceName]
+    );
+    if (existingInterface.length === 0) {
+      await conn.rollback();
+      return res.status(404).json({ error: 'Interface not found for this machine' });
+    }
+    await replaceNeighbors(conn, machineId, interfaceName, lldp_neighbors);
+
+    await conn.commit();
+    res.json({ message: 'LLDP neighbors updated' });
+  } catch (err) {
+    await conn.rollback();
+    res.status(500).json({ error: err.message });
+  } finally {
+    conn.release();
+  }
+});
+
+// POST store the latest report of a given kind sent by the agent
+app.post('/api/machines/:id/reports', async (req, res) => {
+  const machineId = req.params.id;
+  const { kind, payload } = req.body;
+
+  // Validate UUID format for machine ID
+  if (!/^[0-9a-fA-F]{8}\b-[0-9a-fA-F]{4}\b-[0-9a-fA-F]{4}\b-[0-9a-fA-F]{4}\b-[0-9a-fA-F]{12}$/.test(machineId)) {
+    return res.status(400).json({ error: 'Invalid machine UUID format' });
+  }
+
+  // Validate report kind and payload
+  if (typeof kind !== 'string' || !/^[a-z0-9_]{1,64}$/.test(kind)) {
+    return res.status(400).json({ error: 'Kind must be a lowercase identifier' });
+  }
+  if (payload === undefined) {
+    return res.status(400).json({ error: 'Payload is required' });
+  }
+
+  try {
+    const [machines] = await db.query('SELECT id FROM machines WHERE id = ?', [machineId]);
+    if (machines.length === 0) {
+      return res.status(404).json({ error: 'Machine not found' });
+    }
+
+    await db.query(
+      'INSERT INTO machine_reports (machine_id, kind, payload) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE payload = VALUES(payload), updated_at = CURRENT_TIMESTAMP',
+      [machineId, kind, JSON.stringify(payload)]
+    );
+    res.json({ message: 'Report stored' });
+  } catch (err) {
+    res.status(500).json({ error: err.message });
+  }
+});
+
+// GET latest reports for a machine, optionally filtered by kind
+app.get('/api/machines/:id/reports', async (req, res) => {
+  const machineId = req.params.id;
+
+  // Validate UUID format for machine ID
+  if (!/^[0-9a-fA-F]{8}\b-[0-9a-fA-F]{4}\b-[0-9a-fA-F]{4}\b-[0-9a-fA-F]{4}\b-[0-9a-fA-F]{12}$/.test(machineId)) {
+    return res.status(400).json({ error: 'Invalid machine UUID format' });
+  }
+
+  try {
+    let query = 'SELECT kind, payload, updated_at FROM machine_reports WHERE machine_id = ?';
+    const params = [machineId];
+    if (req.query.kind) {
+      query += ' AND kind = ?';
+      params.push(req.query.kind);
+    }
+
+    const [reports] = await db.query(query, params);
+    res.json(reports);
+  } catch (err) {
+    res.status(500).json({ error: err.message });
+  }
+});
+
+// POST apply a package update sent by the agent: the full list on the first
+// sync, afterwards only the packages added, removed or upgraded. A delta is
+// rejected with 409 unless it was computed against the list on record, and
+// the agent then falls back to a full upload.
+app.post('/api/machines/:id/packages', async (req, res) => {
+  const machineId = req.params.id;
+  const { full, base, hash, count, packages, added, removed, upgraded } = req.body;
+
+  // Validate UUID format for machine ID
+  if (!/^[0-9a-fA-F]{8}\b-[0-9a-fA-F]{4}\b-[0-9a-fA-F]{4}\b-[0-9a-fA-F]{4}\b-[0-9a-fA-F]{12}$/.test(machineId)) {
+    return res.status(400).json({ error: 'Invalid machine UUID format' });
+  }
+  if (typeof hash !== 'string' || !/^[0-9a-f]{64}$/.test(hash)) {
+    return res.status(400).json({ error: 'Hash must be a SHA-256 hex digest' });
+  }
+
+  const conn = await db.getConnection();
+  try {
+    await conn.beginTransaction();
+
+    const [machines] = await conn.query('SELECT id FROM machines WHERE id = ? FOR UPDATE', [machineId]);
+    if (machines.length === 0) {
+      await conn.rollback();
+      return res.status(404).json({ error: 'Machine not found' });
+    }
+
+    const upsert = ({ manager, name, arch, version, source }) => conn.query(
+      'INSERT INTO machine_packages (machine_id, manager, name, arch, version, source) VALUES (?, ?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE version = VALUES(version), source = VALUES(source)',
+      [machineId, manager, name, arch || '', version, source || null]
+    );
+
+    if (full) {
+      await conn.query('DELETE FROM machine_packages WHERE machine_id = ?', [machineId]);
+      for (const pkg of packages || []) {
+        await upsert(pkg);
+      }
+    } else {
+      const [state] = await conn.query(
+        "SELECT JSON_UNQUOTE(JSON_EXTRACT(payload, '$.hash')) AS hash FROM machine_reports WHERE machine_id = ? AND kind = 'packages'",
+        [machineId]
+      );
+      if (state.length === 0 || state[0].hash !== base) {
+        await conn.rollback();
+        return res.status(409).json({ error: 'Package list on record does not match the delta base' });
+      }
+      for (const { manager, name, arch } of removed || []) {
+        await conn.query(
+          'DELETE FROM machine_packages WHERE machine_id = ? AND manager = ? AND name = ? AND arch = ?',
+          [machineId, manager, name, arch || '']
+        );
+      }
+      for (const pkg of [...(added || []), ...(upgraded || [])]) {
+        await upsert(pkg);
+      }
+    }
+
+    await conn.query(
+      'INSERT INTO machine_reports (machine_id, kind, payload) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE payload = VALUES(payload), updated_at = CURRENT_TIMESTAMP',
+      [machineId, 'packages', JSON.stringify({ hash, count })]
+    );
+
+    await conn.commit();
+    res.json({ message: 'Packages updated' });
+  } catch (err) {
+    await conn.rollback();
+    res.status(500).json({ error: err.message });
+  } finally {
+    conn.release();
+  }
+});
+
+// GET installed packages of a machine
+app.get('/api/machines/:id/packages', async (req, res) => {
+  const machineId = req.params.id;
+
+  // Validate UUID format for machine ID
+  if (!/^[0-9a-fA-F]{8}\b-[0-9a-fA-F]{4}\b-[0-9a-fA-F]{4}\b-[0-9a-fA-F]{4}\b-[0-9a-fA-F]{12}$/.test(machineId)) {
+    return res.status(400).json({ error: 'Invalid machine UUID format' });
+  }
+
+  try {
+    const [packages] = await db.query(
+      'SELECT manager, name, arch, version, source, updated_at FROM machine_packages WHERE machine_id = ? ORDER BY manager, name, arch',
+      [machineId]
+    );
+    res.json(packages);
+  } catch (err) {
+    res.status(500).json({ error: err.message });
+  }
+});
+
+// GET machines with a package installed, matched by binary or source package
+// name and optionally an exact version
+app.get('/api/packages', async (req, res) => {
+  const { name, version } = req.query;
+  if (!name) {
+    return res.status(400).json({ error: 'Package name is required' });
+  }
+
+  try {
+    let query = `
+      SELECT m.id AS machine_id, m.hostname, p.manager, p.name, p.arch, p.version, p.source
+      FROM machine_packages p
+      JOIN machines m ON m.id = p.machine_id
+      WHERE (p.name = ? OR p.source = ?)`;
+    const params = [name, name];
+    if (version) {
+      query += ' AND p.version = ?';
+      params.push(version);
+    }
+    query += ' ORDER BY m.hostname, p.name';
+
+    const [rows] = await db.query(query, params);
+    res.json(rows);
+  } catch (err) {
+    res.status(500).json({ error: err.message });
+  }
+});
+
+// GET the SSH host keys of every machine with its hostname and registered
+// IPs, for building a fleet-wide known_hosts file
+app.get('/api/ssh-host-keys', async (req, res) => {
+  try {
+    const [keys] = await db.query(`
+      SELECT m.id, m.hostname, k.key_type, k.public_key, k.fingerprint
+      FROM machines m
+      JOIN machine_ssh_host_keys k ON m.id = k.machine_id
+      ORDER BY m.hostname, m.id, k.key_type
+    `);
+    const [ips] = await db.query(`
+      SELECT DISTINCT i.machine_id, ip.ip_address
+      FROM interfaces i
+      JOIN interface_ips ip ON i.id = ip.interface_id
+      ORDER BY ip.ip_address
+    `);
+
+    const machines = new Map();
+    for (const { id, hostname, key_type, public_key, fingerprint } of keys) {
+      if (!machines.has(id)) {
+        machines.set(id, { id, hostname, ips: [], host_keys: [] });
+      }
+      machines.get(id).host_keys.push({ type: key_type, public_key, fingerprint });
+    }
+    for (const { machine_id, ip_address } of ips) {
+      if (machines.has(machine_id)) {
+        machines.get(machine_id).ips.push(ip_address);
+      }
+    }
+
+    res.json([...machines.values()]);
+  } catch (err) {
+    res.status(500).json({ error: err.message });
+  }
+});
+
 // GET IP addresses with dns_register flag set to ON, grouped by hostname
 app.get('/api/dns-register', async (req, res) => {
   try {
@@ -28,6 +28,7 @@ CREATE TABLE interfaces (
   gateway VARCHAR(45),
   dns_servers TEXT, -- Comma-separated list of DNS servers
   mac_address VARCHAR(17), -- MAC address field (e.g., '00:1A:2B:3C:4D:5E')
+  mtu INT, -- Desired MTU; NULL leaves the interface default
   FOREIGN KEY (machine_id) REFERENCES machines(id) ON DELETE CASCADE
 );
 
@@ -39,3 +40,53 @@ CREATE TABLE interface_ips (
   dns_register BOOLEAN DEFAULT FALSE,
   FOREIGN KEY (interface_id) REFERENCES interfaces(id) ON DELETE CASCADE
 );
+
+CREATE TABLE machine_reports (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  machine_id CHAR(36) NOT NULL,
+  kind VARCHAR(64) NOT NULL, -- Report type sent by the agent (e.g. 'validation')
+  payload JSON NOT NULL,
+  updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  UNIQUE KEY machine_kind (machine_id, kind),
+  FOREIGN KEY (machine_id) REFERENCES machines(id) ON DELETE CASCADE
+);
+
+CREATE TABLE interface_lldp_neighbors (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  machine_id CHAR(36) NOT NULL,
+  interface_name VARCHAR(50) NOT NULL, -- Keyed by name so edits to the interface rows keep it
+  chassis_id VARCHAR(255),
+  system_name VARCHAR(255),
+  port_id VARCHAR(255),
+  port_description VARCHAR(255),
+  management_address VARCHAR(45),
+  vlan_id INT,
+  vlan_name VARCHAR(255),
+  updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  KEY machine_interface (machine_id, interface_name),
+  FOREIGN KEY (machine_id) REFERENCES machines(id) ON DELETE CASCADE
+);
+
+CREATE TABLE machine_packages (
+  machine_id CHAR(36) NOT NULL,
+  manager VARCHAR(16) NOT NULL, -- Package manager ('dpkg' or 'rpm')
+  name VARCHAR(255) NOT NULL,
+  arch VARCHAR(32) NOT NULL DEFAULT '',
+  version VARCHAR(255) NOT NULL,
+  source VARCHAR(255), -- Source package the binary package was built from
+  updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (machine_id, manager, name, arch),
+  KEY package_name (name),
+  KEY package_source (source),
+  FOREIGN KEY (machine_id) REFERENCES machines(id) ON DELETE CASCADE
+);
+
+CREATE TABLE machine_ssh_host_keys (
+  machine_id CHAR(36) NOT NULL,
+  key_type VARCHAR(64) NOT NULL, -- e.g. 'ssh-ed25519'
+  public_key TEXT NOT NULL, -- '<type> <base64>' as written to known_hosts
+  fingerprint VARCHAR(64) NOT NULL, -- 'SHA256:...' as printed by ssh-keygen -l
+  updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (machine_id, key_type),
+  FOREIGN KEY (machine_id) REFERENCES machines(id) ON DELETE CASCADE
+);
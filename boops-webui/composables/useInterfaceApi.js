@@ -33,6 +33,22 @@ export function useInterfaceApi() {
     }
   };
 
+  const updateInterfaceMtu = async (machineId, interfaceName, mtu) => {
+    const response = await fetch(
+      `${apiBaseUrl}/interfaces/${machineId}/${interfaceName}/update-mtu`,
+      {
+        method: 'PUT',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ mtu: mtu ? Number(mtu) : null })
+      }
+    );
+
+    if (!response.ok) {
+      const errorData = await response.json();
+      throw new Error(errorData.error || 'Failed to update MTU');
+    }
+  };
+
   const updateInterfaceIps = async (machineId, interfaceName, ips) => {
     const response = await fetch(
       `${apiBaseUrl}/interfaces/${machineId}/${interfaceName}/ips`,
@@ -95,6 +111,7 @@ export function useInterfaceApi() {
   return {
     updateInterfaceGateway,
     updateInterfaceDns,
+    updateInterfaceMtu,
     updateInterfaceIps,
     updateInterfaceName,
     createInterface,